@@ -3,13 +3,13 @@ package main
 import (
 	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/osamikoyo/dark-fantasy-land/internal/config"
+	"github.com/osamikoyo/dark-fantasy-land/internal/transport/server"
 	"github.com/osamikoyo/dark-fantasy-land/pkg/logger"
-	"github.com/osamikoyo/dark-fantasy-land/pkg/retrier"
-	"github.com/redis/go-redis/v9"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 )
 
@@ -33,36 +33,19 @@ func main() {
 
 	logger.Info("starting dark-fantasy land...", zap.Any("cfg", cfg))
 
-	db, err := retrier.Connect(3, 5, func() (*mongo.Database, error) {
-		client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(cfg.MongoUrl))
-		if err != nil {
-			return nil, err
-		}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
 
-		return client.Database("dark-fantasy"), nil
-	})
+	srv, err := server.NewServer(cfg, logger)
 	if err != nil {
-		logger.Error("failed connecto to mongodb", zap.Error(err))
+		logger.Error("failed create server", zap.Error(err))
 
-		return
+		os.Exit(1)
 	}
 
-	logger.Info("successfully connected to mongo db")
-
-	redisDB, err := retrier.Connect(3, 5, func() (*redis.Client, error) {
-		client := redis.NewClient(&redis.Options{
-			Addr:     cfg.RedisUrl,
-			Password: "",
-			DB:       0,
-		})
-
-		return client, client.Ping(context.Background()).Err()
-	})
-	if err != nil {
-		logger.Error("failed connect to redis", zap.Error(err))
+	if err = srv.Run(ctx); err != nil {
+		logger.Error("server stopped with error", zap.Error(err))
 
-		return
+		os.Exit(1)
 	}
-
-	logger.Info("successfully connected to redis")
 }
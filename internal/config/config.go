@@ -1,15 +1,90 @@
 package config
 
-import "os"
+import (
+	"errors"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	CensorExternal = "external"
+	CensorLocal    = "local"
+)
+
+const (
+	DefaultMaxUploadBytes  = 10 << 20
+	DefaultMaxUploadPixels = 40_000_000
+
+	DefaultMaxGifFrames  = 500
+	DefaultMaxGifSeconds = 60
+	DefaultMaxGifSide    = 720
+	// DefaultMaxGifPixels bounds what decoding all frames of an animation
+	// allocates, a byte per pixel.
+	DefaultMaxGifPixels = 200_000_000
+)
+
+// What happens to an upload that looks like existing content.
+const (
+	DuplicatesReject = "reject"
+	DuplicatesFlag   = "flag"
+
+	DefaultDuplicateDistance = 5
+)
+
+// DevSecret signs tokens of a development server started with DEV_MODE and
+// no JWT_SECRET, it is public and must never reach a deployment.
+const DevSecret = "dark-fantasy-land-dev-secret"
+
+// DevWatermarkKey keys the invisible mark of a development server started
+// with DEV_MODE and no WATERMARK_KEY.
+const DevWatermarkKey = "dark-fantasy-land-dev-watermark"
+
+var (
+	ErrMissingJWTSecret    = errors.New("JWT_SECRET is not set")
+	ErrMissingWatermarkKey = errors.New("WATERMARK_KEY is not set")
+	ErrSharedWatermarkKey  = errors.New("WATERMARK_KEY must differ from JWT_SECRET")
+)
+
+const (
+	DefaultWatermarkCorner  = "bottom-right"
+	DefaultWatermarkOpacity = 60
+	DefaultWatermarkRenders = 2
+)
 
 type (
 	Buckets struct {
-		WallpaperFull  string
+		WallpaperFull string
+		// WallpaperWatch holds the resized renditions of every wallpaper.
 		WallpaperWatch string
 		Mems           string
+		Avatars        string
+		Previews       string
+		MemTemplates   string
+	}
+
+	// Watermark places the visible mark and keys the invisible one of
+	// wallpaper downloads.
+	Watermark struct {
+		Corner  string
+		Opacity float64
+		Key     string `json:"-"`
+		// Renders bounds how many marked downloads are rendered at once.
+		Renders int
+	}
+
+	// Duplicates tells how uploads within Distance bits of the perceptual
+	// hash of existing content are treated.
+	Duplicates struct {
+		Mode     string
+		Distance int
 	}
 
 	Config struct {
+		// Dev allows the development fallbacks, like DevSecret.
+		Dev            bool
 		Port           string
 		Host           string
 		MongoUrl       string
@@ -20,10 +95,46 @@ type (
 		MinioSecretKey string
 		MinioBuckets   Buckets
 		MinioSSL       bool
+		JWTSecret      string `json:"-"`
+		AccessTTL      time.Duration
+		RefreshTTL     time.Duration
+		Admins         []string
+		CensorMode     string
+		CensorRules    string
+
+		// MaxUploadBytes and MaxUploadPixels cap every uploaded image.
+		MaxUploadBytes  int64
+		MaxUploadPixels int64
+
+		// Animated mems are refused past MaxGifFrames, MaxGifPixels over all
+		// frames or MaxGifDuration and scaled down to MaxGifSide.
+		MaxGifFrames   int
+		MaxGifPixels   int64
+		MaxGifDuration time.Duration
+		MaxGifSide     int
+
+		// ImageWorkers bounds how many uploads are processed at once.
+		ImageWorkers int
+		Duplicates   Duplicates
+		Watermark    Watermark
+
+		// KeepMetadata names the metadata fields of an upload, artist and
+		// copyright, copied to the content before the rest is stripped.
+		KeepMetadata []string
 	}
 )
 
 func NewConfig() *Config {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	host := os.Getenv("HOST")
+	if host == "" {
+		host = "localhost"
+	}
+
 	url := os.Getenv("MONGO_URI")
 	if url == "" {
 		url = "mongodb://mongo:27017"
@@ -35,8 +146,8 @@ func NewConfig() *Config {
 	}
 
 	natsUrl := os.Getenv("NATS_URI")
-	if redisUrl == "" {
-		redisUrl = "nats://nats:4222"
+	if natsUrl == "" {
+		natsUrl = "nats://nats:4222"
 	}
 
 	minioUrl := os.Getenv("MINIO_URI")
@@ -44,9 +155,58 @@ func NewConfig() *Config {
 		minioUrl = "minio:9000"
 	}
 
+	dev := envBool("DEV_MODE")
+
+	// a missing secret is refused by Validate outside of dev mode.
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" && dev {
+		jwtSecret = DevSecret
+	}
+
+	// the external censor is the default, "local" judges content in process
+	// with the rules from CENSOR_RULES.
+	censorMode := os.Getenv("CENSOR_MODE")
+	if censorMode == "" {
+		censorMode = CensorExternal
+	}
+
+	maxUploadBytes := envInt("MAX_UPLOAD_BYTES", DefaultMaxUploadBytes)
+	maxUploadPixels := envInt("MAX_UPLOAD_PIXELS", DefaultMaxUploadPixels)
+	maxGifFrames := envInt("MAX_GIF_FRAMES", DefaultMaxGifFrames)
+	maxGifSeconds := envInt("MAX_GIF_SECONDS", DefaultMaxGifSeconds)
+	maxGifSide := envInt("MAX_GIF_SIDE", DefaultMaxGifSide)
+	maxGifPixels := envInt("MAX_GIF_PIXELS", DefaultMaxGifPixels)
+	imageWorkers := envInt("IMAGE_WORKERS", int64(runtime.NumCPU()))
+
+	duplicates := Duplicates{
+		Mode:     DuplicatesReject,
+		Distance: int(envInt("DUPLICATE_DISTANCE", DefaultDuplicateDistance)),
+	}
+	if os.Getenv("DUPLICATE_MODE") == DuplicatesFlag {
+		duplicates.Mode = DuplicatesFlag
+	}
+
+	// every wallpaper may ask for the invisible mark, so its key is as
+	// required as the jwt secret and never the same.
+	watermark := Watermark{
+		Corner:  os.Getenv("WATERMARK_CORNER"),
+		Opacity: float64(min(100, envInt("WATERMARK_OPACITY", DefaultWatermarkOpacity))) / 100,
+		Key:     os.Getenv("WATERMARK_KEY"),
+		Renders: int(envInt("WATERMARK_RENDERS", DefaultWatermarkRenders)),
+	}
+	if watermark.Corner == "" {
+		watermark.Corner = DefaultWatermarkCorner
+	}
+	if watermark.Key == "" && dev {
+		watermark.Key = DevWatermarkKey
+	}
+
+	admins := envList("ADMIN_USERS")
+
 	return &Config{
-		Port:           "8080",
-		Host:           "localhost",
+		Dev:            dev,
+		Port:           port,
+		Host:           host,
 		MongoUrl:       url,
 		RedisUrl:       redisUrl,
 		NatsUrl:        natsUrl,
@@ -54,10 +214,80 @@ func NewConfig() *Config {
 		MinioAccessKey: "minioadmin",
 		MinioSecretKey: "minioadmin",
 		MinioBuckets: Buckets{
-			WallpaperFull:  "wallpaper_full",
-			WallpaperWatch: "wallpaper_watch",
+			WallpaperFull:  "wallpaper-full",
+			WallpaperWatch: "wallpaper-watch",
 			Mems:           "mem",
+			Avatars:        "avatar",
+			Previews:       "preview",
+			MemTemplates:   "mem-template",
 		},
-		MinioSSL: false,
+		MinioSSL:    false,
+		JWTSecret:   jwtSecret,
+		AccessTTL:   15 * time.Minute,
+		RefreshTTL:  7 * 24 * time.Hour,
+		Admins:      admins,
+		CensorMode:  censorMode,
+		CensorRules: os.Getenv("CENSOR_RULES"),
+
+		MaxUploadBytes:  maxUploadBytes,
+		MaxUploadPixels: maxUploadPixels,
+		MaxGifFrames:    int(maxGifFrames),
+		MaxGifPixels:    maxGifPixels,
+		MaxGifDuration:  time.Duration(maxGifSeconds) * time.Second,
+		MaxGifSide:      int(maxGifSide),
+		ImageWorkers:    int(imageWorkers),
+		Duplicates:      duplicates,
+		Watermark:       watermark,
+		KeepMetadata:    envList("KEEP_METADATA"),
+	}
+}
+
+// Validate refuses a configuration the server must not start with.
+func (c *Config) Validate() error {
+	if c.JWTSecret == "" {
+		return ErrMissingJWTSecret
+	}
+
+	if c.Watermark.Key == "" {
+		return ErrMissingWatermarkKey
 	}
+
+	if c.Watermark.Key == c.JWTSecret {
+		return ErrSharedWatermarkKey
+	}
+
+	return nil
+}
+
+// envBool reads a flag from the environment, anything strconv does not
+// take as true is false.
+func envBool(name string) bool {
+	on, err := strconv.ParseBool(os.Getenv(name))
+
+	return err == nil && on
+}
+
+// envList reads a comma separated list from the environment, lower cased
+// and without empty items.
+func envList(name string) []string {
+	var list []string
+
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, strings.ToLower(item))
+		}
+	}
+
+	return list
+}
+
+// envInt reads a positive integer from the environment, anything else falls
+// back to the default.
+func envInt(name string, fallback int64) int64 {
+	n, err := strconv.ParseInt(os.Getenv(name), 10, 64)
+	if err != nil || n <= 0 {
+		return fallback
+	}
+
+	return n
 }
@@ -1,11 +1,21 @@
 package entity
 
-import "time"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type Article struct {
-	Title     string    `bson:"title" redis:"title" mapstructure:"title"`
-	Topics    []string  `bson:"topics" redis:"topics" mapstructure:"topics"`
-	Timestamp time.Time `bson:"timestamp" redis:"timestamp" mapstructure:"timestamp"`
-	Content   string    `bson:"content" redis:"content" mapstructure:"content"`
-	Author    string    `bson:"author" redis:"author" mapstructure:"author"`
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Slug      string             `bson:"slug"`
+	Title     string             `bson:"title"`
+	Topics    []string           `bson:"topics"`
+	Timestamp time.Time          `bson:"timestamp"`
+	Views     int64              `bson:"views"`
+	Status    string             `bson:"status"`
+	Revision  int64              `bson:"revision,omitempty"`
+	Rating    uint8              `bson:"rating"`
+	Content   string             `bson:"content"`
+	Author    string             `bson:"author"`
 }
@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DeadLetter is a message the consumer gave up on, kept with the reason so
+// it can be inspected and replayed once the cause is fixed.
+type DeadLetter struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Subject   string             `bson:"subject" json:"subject"`
+	Kind      string             `bson:"kind" json:"kind"`
+	Payload   string             `bson:"payload" json:"payload"`
+	Error     string             `bson:"error" json:"error"`
+	Attempts  uint64             `bson:"attempts" json:"attempts"`
+	Timestamp time.Time          `bson:"timestamp" json:"timestamp"`
+}
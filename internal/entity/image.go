@@ -0,0 +1,84 @@
+package entity
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Processing states of an upload, content stored before uploads were
+// processed asynchronously has none and counts as ready.
+const (
+	ProcessingQueued = "queued"
+	ProcessingReady  = "ready"
+	ProcessingFailed = "failed"
+)
+
+// Watermarks a wallpaper download may carry, none when empty.
+const (
+	WatermarkVisible   = "visible"
+	WatermarkInvisible = "invisible"
+	WatermarkBoth      = "both"
+)
+
+func ValidWatermark(watermark string) bool {
+	switch watermark {
+	case "", WatermarkVisible, WatermarkInvisible, WatermarkBoth:
+		return true
+	default:
+		return false
+	}
+}
+
+// ImageJob asks the image workers to process the upload of one document.
+type ImageJob struct {
+	Kind string
+	ID   primitive.ObjectID
+}
+
+// ImageEvent tells where processing of an upload stands. It is published
+// once a job is finished and served to clients polling for it.
+type ImageEvent struct {
+	Kind       string
+	ID         primitive.ObjectID
+	Status     string
+	Renditions []Rendition
+	Error      string `json:",omitempty"`
+}
+
+// Duplicate points at the content an upload was found to repeat.
+type Duplicate struct {
+	Kind     string             `bson:"kind"`
+	ID       primitive.ObjectID `bson:"id"`
+	Slug     string             `bson:"slug"`
+	Distance int                `bson:"distance"`
+}
+
+// SimilarWallpaper is a wallpaper found by its hash, Distance is how many
+// bits of the hashes differ.
+type SimilarWallpaper struct {
+	Wallpaper
+	Distance int
+}
+
+// Animation describes an animated mem, Duration is one pass in
+// milliseconds and LoopCount follows image/gif: 0 loops forever, -1 plays
+// once and n plays n+1 times.
+type Animation struct {
+	Frames    int   `bson:"frames"`
+	Duration  int64 `bson:"duration_ms"`
+	LoopCount int   `bson:"loop_count"`
+}
+
+// WatermarkMatch is what was read from an image sent for verification.
+// Downloader is only told to the author and moderators.
+type WatermarkMatch struct {
+	Found      bool
+	Wallpaper  primitive.ObjectID
+	Slug       string
+	Author     string
+	Downloader string
+}
+
+// Credits are the metadata fields of an upload the deployment keeps, the
+// rest is stripped before the image is stored.
+type Credits struct {
+	Artist    string `bson:"artist,omitempty"`
+	Copyright string `bson:"copyright,omitempty"`
+}
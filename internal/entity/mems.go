@@ -1,11 +1,28 @@
 package entity
 
-import "time"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type Mem struct {
-	ImageName   string    `bson:"image_name"`
-	Topics      []string  `bson:"topics"`
-	Author      string    `bson:"author"`
-	Timestamp   time.Time `bson:"timestamp"`
-	Description string    `bson:"description"`
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Slug        string             `bson:"slug"`
+	ImageName   string             `bson:"image_name"`
+	Topics      []string           `bson:"topics"`
+	Author      string             `bson:"author"`
+	Timestamp   time.Time          `bson:"timestamp"`
+	Views       int64              `bson:"views"`
+	Status      string             `bson:"status"`
+	Revision    int64              `bson:"revision,omitempty"`
+	Rating      uint8              `bson:"rating"`
+	Description string             `bson:"description"`
+	Hash        string             `bson:"hash,omitempty"`
+	HashBands   []string           `bson:"hash_bands,omitempty" json:"-"`
+	DuplicateOf *Duplicate         `bson:"duplicate_of,omitempty"`
+	// Poster is the still first frame of an animated mem, shown in lists.
+	Poster    string     `bson:"poster,omitempty"`
+	Animation *Animation `bson:"animation,omitempty"`
+	Credits   *Credits   `bson:"credits,omitempty"`
 }
@@ -1,12 +1,21 @@
 package entity
 
-import "time"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type New struct {
-	Title     string    `bson:"title"`
-	Topic     string    `bson:"topic"`
-	Author    string    `bson:"author"`
-	Censor    uint8     `bson:"censor"`
-	Content   string    `bson:"content"`
-	Timestamp time.Time `bson:"timestamp"`
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Slug      string             `bson:"slug"`
+	Title     string             `bson:"title"`
+	Topic     string             `bson:"topic"`
+	Author    string             `bson:"author"`
+	Censor    uint8              `bson:"censor"`
+	Content   string             `bson:"content"`
+	Timestamp time.Time          `bson:"timestamp"`
+	Views     int64              `bson:"views"`
+	Status    string             `bson:"status"`
+	Revision  int64              `bson:"revision,omitempty"`
 }
@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OutboxMessage is a message written together with the change it announces
+// and published later by the relay. SentAt stays nil until the broker has
+// stored it.
+type OutboxMessage struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Subject     string             `bson:"subject"`
+	Payload     []byte             `bson:"payload"`
+	CreatedAt   time.Time          `bson:"created_at"`
+	SentAt      *time.Time         `bson:"sent_at"`
+	LockedUntil *time.Time         `bson:"locked_until"`
+	Attempts    int                `bson:"attempts"`
+	LastError   string             `bson:"last_error,omitempty"`
+}
@@ -0,0 +1,11 @@
+package entity
+
+// PaletteColor is one dominant color of an image in sRGB and Lab, with the
+// share of the image it covers.
+type PaletteColor struct {
+	Hex    string  `bson:"hex"`
+	L      float64 `bson:"l"`
+	A      float64 `bson:"a"`
+	B      float64 `bson:"b"`
+	Weight float64 `bson:"weight"`
+}
@@ -0,0 +1,35 @@
+package entity
+
+// Maturity ratings, ordered from the mildest. New keeps its rating in
+// Censor, the other kinds in Rating.
+const (
+	RatingAllAges uint8 = iota
+	RatingHorror
+	RatingGore
+	RatingExplicit
+)
+
+var ratingNames = []string{"all-ages", "horror", "gore", "explicit"}
+
+// ParseRating accepts a rating by name.
+func ParseRating(name string) (uint8, bool) {
+	for rating, known := range ratingNames {
+		if name == known {
+			return uint8(rating), true
+		}
+	}
+
+	return 0, false
+}
+
+func RatingName(rating uint8) string {
+	if int(rating) >= len(ratingNames) {
+		return ""
+	}
+
+	return ratingNames[rating]
+}
+
+func ValidRating(rating uint8) bool {
+	return rating <= RatingExplicit
+}
@@ -0,0 +1,10 @@
+package entity
+
+// Rendition is one resized copy of an uploaded image.
+type Rendition struct {
+	Name   string `bson:"name"`
+	Key    string `bson:"key"`
+	Width  int    `bson:"width"`
+	Height int    `bson:"height"`
+	Bytes  int64  `bson:"bytes"`
+}
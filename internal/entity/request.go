@@ -1,10 +1,38 @@
 package entity
 
-import "time"
+import (
+	"time"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+	StatusAppealed = "appealed"
+)
+
+const (
+	KindArticle   = "article"
+	KindNews      = "news"
+	KindMem       = "mem"
+	KindWallpaper = "wallpaper"
+)
+
+// Request is one moderation verdict. The censor sends it with Payload["id"]
+// set and Censored telling whether the content was blocked; the service
+// fills in the rest and keeps every verdict as the audit trail of the
+// content it was about.
 type Request struct {
-	CensoredAt  time.Time
-	Payload     map[string]string
-	Censored    bool
-	Description string
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Kind        string             `bson:"kind"`
+	ContentID   primitive.ObjectID `bson:"content_id"`
+	From        string             `bson:"from"`
+	To          string             `bson:"to"`
+	Moderator   string             `bson:"moderator"`
+	CensoredAt  time.Time          `bson:"censored_at"`
+	Payload     map[string]string  `bson:"payload,omitempty"`
+	Censored    bool               `bson:"censored"`
+	Description string             `bson:"description"`
 }
@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type (
+	// SearchHit is one matched document of any searchable type, Text holds
+	// the matched body only until the snippet is cut from it.
+	SearchHit struct {
+		Type      string             `json:"type"`
+		ID        primitive.ObjectID `json:"id"`
+		Slug      string             `json:"slug"`
+		Title     string             `json:"title,omitempty"`
+		Snippet   string             `json:"snippet"`
+		Topics    []string           `json:"topics,omitempty"`
+		Timestamp time.Time          `json:"timestamp"`
+		Score     float64            `json:"score"`
+		Text      string             `json:"-"`
+	}
+
+	SearchResult struct {
+		Hits   []SearchHit      `json:"hits"`
+		Facets map[string]int64 `json:"facets"`
+	}
+)
@@ -0,0 +1,32 @@
+package entity
+
+import "time"
+
+const (
+	RoleReader    = "reader"
+	RoleAuthor    = "author"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+type User struct {
+	Username     string    `bson:"username"`
+	Email        string    `bson:"email"`
+	PasswordHash string    `bson:"password_hash" json:"-"`
+	Role         string    `bson:"role"`
+	DisplayName  string    `bson:"display_name"`
+	Avatar       string    `bson:"avatar"`
+	Bio          string    `bson:"bio"`
+	MaxRating    string    `bson:"max_rating"`
+	Timestamp    time.Time `bson:"timestamp"`
+}
+
+// Profile is what anyone may see of a user, Email is only filled for the
+// user themselves and administrators.
+type Profile struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Avatar      string `json:"avatar"`
+	Bio         string `json:"bio"`
+	Email       string `json:"email,omitempty"`
+}
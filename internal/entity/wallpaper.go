@@ -1,7 +1,36 @@
 package entity
 
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
 type Wallpaper struct {
-	ImageName  string `bson:"image_name"`
-	Topic      string `bson:"topic"`
-	Resolution string `bson:"resolution"`
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	Slug       string             `bson:"slug"`
+	ImageName  string             `bson:"image_name"`
+	Topic      string             `bson:"topic"`
+	Author     string             `bson:"author"`
+	Resolution string             `bson:"resolution"`
+	Timestamp  time.Time          `bson:"timestamp"`
+	Views      int64              `bson:"views"`
+	Status     string             `bson:"status"`
+	Revision   int64              `bson:"revision,omitempty"`
+	Rating     uint8              `bson:"rating"`
+	Renditions []Rendition        `bson:"renditions"`
+	Processing string             `bson:"processing"`
+	// ProcessingError says why the renditions could not be made.
+	ProcessingError string     `bson:"processing_error,omitempty"`
+	Hash            string     `bson:"hash,omitempty"`
+	HashBands       []string   `bson:"hash_bands,omitempty" json:"-"`
+	DuplicateOf     *Duplicate `bson:"duplicate_of,omitempty"`
+	Watermark       string     `bson:"watermark,omitempty"`
+	// Palette holds the dominant colors, the most covering first.
+	Palette   []PaletteColor `bson:"palette,omitempty"`
+	ColorBins []string       `bson:"color_bins,omitempty" json:"-"`
+	Credits   *Credits       `bson:"credits,omitempty"`
+	// Discarded is set once a rejection removed the images, the wallpaper
+	// can not be appealed or resubmitted after that.
+	Discarded bool `bson:"discarded,omitempty"`
 }
@@ -0,0 +1,178 @@
+package query
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type (
+	// TimeRange bounds Timestamp, a nil end leaves that side open.
+	TimeRange struct {
+		From *time.Time
+		To   *time.Time
+	}
+
+	// Ref points at one document either by id or by slug.
+	Ref struct {
+		ID   primitive.ObjectID
+		Slug string
+	}
+
+	// ArticleQuery matches Title as a case-insensitive substring, the other
+	// fields are exact.
+	ArticleQuery struct {
+		Ref
+		Author    string
+		Title     string
+		Topics    []string
+		Published TimeRange
+		Statuses  []string
+		Revision  *int64
+		MaxRating *uint8
+	}
+
+	// MemQuery and WallpaperQuery match HashBands when any of the bands
+	// is shared, see imaging.Bands.
+	MemQuery struct {
+		Ref
+		Author    string
+		Topics    []string
+		Published TimeRange
+		Statuses  []string
+		Revision  *int64
+		MaxRating *uint8
+		HashBands []string
+	}
+
+	NewQuery struct {
+		Ref
+		Author    string
+		Title     string
+		Topic     string
+		Published TimeRange
+		Statuses  []string
+		Revision  *int64
+		MaxRating *uint8
+	}
+
+	WallpaperQuery struct {
+		Ref
+		Author    string
+		Topic     string
+		Published TimeRange
+		Statuses  []string
+		Revision  *int64
+		MaxRating *uint8
+		HashBands []string
+		// NearColor, #rrggbb, ranks wallpapers by how close their palette
+		// comes to it. ColorBins match any of the bins, see
+		// imaging.NearBins.
+		NearColor string
+		ColorBins []string
+	}
+
+	// SearchQuery is a full-text search over Types, an empty Types searches
+	// everything searchable.
+	SearchQuery struct {
+		Text      string
+		Types     []string
+		Topics    []string
+		Limit     int64
+		MaxRating uint8
+	}
+
+	// RequestQuery selects the verdicts recorded for one piece of content.
+	RequestQuery struct {
+		Kind      string
+		ContentID primitive.ObjectID
+	}
+
+	DeadLetterQuery struct {
+		ID      primitive.ObjectID
+		Subject string
+	}
+
+	UserQuery struct {
+		Username string
+		Email    string
+	}
+
+	// Update lists field changes by name, the names are checked by the
+	// service before an update is built.
+	Update struct {
+		Set   map[string]interface{}
+		Unset []string
+		Inc   map[string]int64
+	}
+)
+
+const (
+	SearchArticles = "article"
+	SearchNews     = "news"
+	SearchMems     = "mem"
+)
+
+var SearchTypes = []string{SearchArticles, SearchNews, SearchMems}
+
+// ParseRef turns a public reference into a Ref, object ids in hex win over
+// slugs because slugs always carry a dash.
+func ParseRef(ref string) Ref {
+	if id, err := primitive.ObjectIDFromHex(ref); err == nil {
+		return Ref{ID: id}
+	}
+
+	return Ref{Slug: ref}
+}
+
+func ByID(id primitive.ObjectID) Ref {
+	return Ref{ID: id}
+}
+
+func (u Update) Empty() bool {
+	return len(u.Set) == 0 && len(u.Unset) == 0 && len(u.Inc) == 0
+}
+
+// Normalize brings equivalent searches to one form, so they share a cache
+// entry: case and spacing of the text and the order of lists do not matter.
+// Topics keep their case because they are matched exactly.
+func (q SearchQuery) Normalize() SearchQuery {
+	q.Text = strings.Join(strings.Fields(strings.ToLower(q.Text)), " ")
+	q.Types = normalizeList(q.Types, true)
+	q.Topics = normalizeList(q.Topics, false)
+
+	if len(q.Types) == 0 {
+		q.Types = slices.Clone(SearchTypes)
+		slices.Sort(q.Types)
+	}
+
+	return q
+}
+
+func IsSearchType(kind string) bool {
+	return slices.Contains(SearchTypes, kind)
+}
+
+func (q SearchQuery) Has(kind string) bool {
+	return slices.Contains(q.Types, kind)
+}
+
+func normalizeList(list []string, lower bool) []string {
+	var out []string
+
+	for _, item := range list {
+		if lower {
+			item = strings.ToLower(item)
+		}
+
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+
+	slices.Sort(out)
+
+	return slices.Compact(out)
+}
@@ -5,8 +5,9 @@ import (
 	"fmt"
 
 	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/pagination"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 )
 
@@ -15,6 +16,10 @@ func (r *Repository) CreateArticle(ctx context.Context, article *entity.Article)
 
 	res, err := r.articlesColl.InsertOne(ctx, article)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("create article: %w", ErrAlreadyExists)
+		}
+
 		r.logger.Error("failed create article", zap.String("title", article.Title), zap.Error(err))
 		return fmt.Errorf("create article: %w", ErrInsertFailed)
 	}
@@ -23,7 +28,12 @@ func (r *Repository) CreateArticle(ctx context.Context, article *entity.Article)
 	return nil
 }
 
-func (r *Repository) UpdateArticle(ctx context.Context, filter, update map[string]interface{}) error {
+func (r *Repository) UpdateArticle(ctx context.Context, q query.ArticleQuery, u query.Update) error {
+	filter, update := articleFilter(q), updateDocument(u)
+	if len(filter) == 0 || len(update) == 0 {
+		return ErrInvalidInput
+	}
+
 	r.logger.Debug("updating article", zap.Any("filter", filter), zap.Any("update", update))
 
 	res, err := r.articlesColl.UpdateOne(ctx, filter, update)
@@ -40,7 +50,12 @@ func (r *Repository) UpdateArticle(ctx context.Context, filter, update map[strin
 	return nil
 }
 
-func (r *Repository) DeleteArticle(ctx context.Context, filter map[string]interface{}) error {
+func (r *Repository) DeleteArticle(ctx context.Context, q query.ArticleQuery) error {
+	filter := articleFilter(q)
+	if len(filter) == 0 {
+		return ErrInvalidInput
+	}
+
 	r.logger.Debug("deleting article", zap.Any("filter", filter))
 	res, err := r.articlesColl.DeleteOne(ctx, filter)
 	if err != nil {
@@ -56,7 +71,12 @@ func (r *Repository) DeleteArticle(ctx context.Context, filter map[string]interf
 	return nil
 }
 
-func (r *Repository) GetArticle(ctx context.Context, filter map[string]interface{}) (*entity.Article, error) {
+func (r *Repository) GetArticle(ctx context.Context, q query.ArticleQuery) (*entity.Article, error) {
+	filter := articleFilter(q)
+	if len(filter) == 0 {
+		return nil, ErrInvalidInput
+	}
+
 	r.logger.Debug("fetching single article", zap.Any("filter", filter))
 
 	res := r.articlesColl.FindOne(ctx, filter)
@@ -79,17 +99,19 @@ func (r *Repository) GetArticle(ctx context.Context, filter map[string]interface
 	return &article, nil
 }
 
-func (r *Repository) GetArticlesLimited(ctx context.Context, filter map[string]interface{}, limit int64) ([]entity.Article, error) {
-	r.logger.Debug("fetching limited articles", zap.Any("filter", filter), zap.Int64("limit", limit))
+func (r *Repository) GetArticlesPage(ctx context.Context, q query.ArticleQuery, page pagination.Request) ([]entity.Article, error) {
+	filter := articleFilter(q)
+
+	r.logger.Debug("fetching articles page", zap.Any("filter", filter), zap.String("sort", string(page.Sort)), zap.Int64("limit", page.Limit))
 
-	findOptions := options.Find()
-	findOptions.SetLimit(limit)
+	query, findOptions := pageQuery(filter, page)
 
-	res, err := r.articlesColl.Find(ctx, filter, findOptions)
+	res, err := r.articlesColl.Find(ctx, query, findOptions)
 	if err != nil {
 		r.logger.Error("failed fetch limited articles", zap.Any("filter", filter), zap.Error(err))
 		return nil, fmt.Errorf("get limited articles: %w", ErrNotFound)
 	}
+	defer res.Close(ctx)
 
 	var articles []entity.Article
 	for res.Next(ctx) {
@@ -106,9 +128,5 @@ func (r *Repository) GetArticlesLimited(ctx context.Context, filter map[string]i
 		return nil, fmt.Errorf("parse articles: %w", ErrDecodeFailed)
 	}
 
-	if len(articles) == 0 {
-		return nil, ErrNoDocuments
-	}
-
 	return articles, nil
 }
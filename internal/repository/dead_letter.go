@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/pagination"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+func (r *Repository) CreateDeadLetter(ctx context.Context, letter *entity.DeadLetter) error {
+	res, err := r.deadColl.InsertOne(ctx, letter)
+	if err != nil {
+		r.logger.Error("failed store dead letter", zap.String("subject", letter.Subject), zap.Error(err))
+		return fmt.Errorf("create dead letter: %w", ErrInsertFailed)
+	}
+
+	r.logger.Warn("dead letter stored",
+		zap.String("subject", letter.Subject),
+		zap.String("inserted_id", fmt.Sprintf("%v", res.InsertedID)))
+	return nil
+}
+
+func (r *Repository) GetDeadLetter(ctx context.Context, q query.DeadLetterQuery) (*entity.DeadLetter, error) {
+	filter := deadLetterFilter(q)
+	if len(filter) == 0 {
+		return nil, ErrInvalidInput
+	}
+
+	var letter entity.DeadLetter
+
+	if err := r.deadColl.FindOne(ctx, filter).Decode(&letter); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+
+		r.logger.Error("failed get dead letter", zap.Error(err))
+		return nil, fmt.Errorf("get dead letter: %w", err)
+	}
+
+	return &letter, nil
+}
+
+func (r *Repository) GetDeadLettersPage(ctx context.Context, q query.DeadLetterQuery, page pagination.Request) ([]entity.DeadLetter, error) {
+	filter := deadLetterFilter(q)
+
+	r.logger.Debug("fetching dead letters page", zap.Any("filter", filter), zap.String("sort", string(page.Sort)), zap.Int64("limit", page.Limit))
+
+	query, findOptions := pageQuery(filter, page)
+
+	res, err := r.deadColl.Find(ctx, query, findOptions)
+	if err != nil {
+		r.logger.Error("failed fetch dead letters", zap.Error(err))
+		return nil, fmt.Errorf("get dead letters: %w", err)
+	}
+	defer res.Close(ctx)
+
+	var letters []entity.DeadLetter
+	if err = res.All(ctx, &letters); err != nil {
+		r.logger.Warn("failed decode dead letters", zap.Error(err))
+		return nil, fmt.Errorf("decode dead letters: %w", ErrDecodeFailed)
+	}
+
+	return letters, nil
+}
+
+// DeleteDeadLetters removes every dead letter matching q, an empty q purges
+// them all.
+func (r *Repository) DeleteDeadLetters(ctx context.Context, q query.DeadLetterQuery) (int64, error) {
+	filter := deadLetterFilter(q)
+
+	res, err := r.deadColl.DeleteMany(ctx, filter)
+	if err != nil {
+		r.logger.Error("failed delete dead letters", zap.Any("filter", filter), zap.Error(err))
+		return 0, fmt.Errorf("delete dead letters: %w", ErrDeleteFailed)
+	}
+
+	r.logger.Info("dead letters deleted", zap.Any("filter", filter), zap.Int64("deleted_count", res.DeletedCount))
+	return res.DeletedCount, nil
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"regexp"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func refFilter(filter bson.M, ref query.Ref) {
+	if !ref.ID.IsZero() {
+		filter["_id"] = ref.ID
+	}
+
+	if ref.Slug != "" {
+		filter["slug"] = ref.Slug
+	}
+}
+
+func timeFilter(filter bson.M, published query.TimeRange) {
+	if published.From == nil && published.To == nil {
+		return
+	}
+
+	bounds := bson.M{}
+	if published.From != nil {
+		bounds["$gte"] = *published.From
+	}
+	if published.To != nil {
+		bounds["$lt"] = *published.To
+	}
+
+	filter["timestamp"] = bounds
+}
+
+func equalFilter(filter bson.M, field, value string) {
+	if value != "" {
+		filter[field] = value
+	}
+}
+
+// containsFilter matches value anywhere in field, the value is quoted so
+// user input never reaches mongo as a pattern.
+func containsFilter(filter bson.M, field, value string) {
+	if value != "" {
+		filter[field] = primitive.Regex{Pattern: regexp.QuoteMeta(value), Options: "i"}
+	}
+}
+
+// statusFilter keeps documents in one of statuses. Content stored before
+// moderation existed has no status and counts as approved.
+func statusFilter(filter bson.M, statuses []string) {
+	if len(statuses) == 0 {
+		return
+	}
+
+	in := bson.A{}
+	for _, status := range statuses {
+		in = append(in, status)
+
+		if status == entity.StatusApproved {
+			in = append(in, nil)
+		}
+	}
+
+	filter["status"] = bson.M{"$in": in}
+}
+
+// revisionFilter matches content at revision, documents never edited have
+// none stored.
+func revisionFilter(filter bson.M, revision *int64) {
+	if revision == nil {
+		return
+	}
+
+	if *revision == 0 {
+		filter["revision"] = bson.M{"$in": bson.A{0, nil}}
+
+		return
+	}
+
+	filter["revision"] = *revision
+}
+
+// ratingFilter keeps documents rated at most highest, unrated ones count as
+// all-ages.
+func ratingFilter(filter bson.M, field string, highest *uint8) {
+	if highest == nil {
+		return
+	}
+
+	filter[field] = bson.M{"$not": bson.M{"$gt": *highest}}
+}
+
+func anyFilter(filter bson.M, field string, values []string) {
+	if len(values) > 0 {
+		filter[field] = bson.M{"$in": values}
+	}
+}
+
+func articleFilter(q query.ArticleQuery) bson.M {
+	filter := bson.M{}
+
+	refFilter(filter, q.Ref)
+	equalFilter(filter, "author", q.Author)
+	containsFilter(filter, "title", q.Title)
+	timeFilter(filter, q.Published)
+	statusFilter(filter, q.Statuses)
+	revisionFilter(filter, q.Revision)
+	ratingFilter(filter, "rating", q.MaxRating)
+
+	if len(q.Topics) > 0 {
+		filter["topics"] = bson.M{"$in": q.Topics}
+	}
+
+	return filter
+}
+
+func memFilter(q query.MemQuery) bson.M {
+	filter := bson.M{}
+
+	refFilter(filter, q.Ref)
+	equalFilter(filter, "author", q.Author)
+	timeFilter(filter, q.Published)
+	statusFilter(filter, q.Statuses)
+	revisionFilter(filter, q.Revision)
+	ratingFilter(filter, "rating", q.MaxRating)
+	anyFilter(filter, "hash_bands", q.HashBands)
+
+	if len(q.Topics) > 0 {
+		filter["topics"] = bson.M{"$in": q.Topics}
+	}
+
+	return filter
+}
+
+func newFilter(q query.NewQuery) bson.M {
+	filter := bson.M{}
+
+	refFilter(filter, q.Ref)
+	equalFilter(filter, "author", q.Author)
+	containsFilter(filter, "title", q.Title)
+	equalFilter(filter, "topic", q.Topic)
+	timeFilter(filter, q.Published)
+	statusFilter(filter, q.Statuses)
+	revisionFilter(filter, q.Revision)
+	ratingFilter(filter, "censor", q.MaxRating)
+
+	return filter
+}
+
+func wallpaperFilter(q query.WallpaperQuery) bson.M {
+	filter := bson.M{}
+
+	refFilter(filter, q.Ref)
+	equalFilter(filter, "author", q.Author)
+	equalFilter(filter, "topic", q.Topic)
+	timeFilter(filter, q.Published)
+	statusFilter(filter, q.Statuses)
+	revisionFilter(filter, q.Revision)
+	ratingFilter(filter, "rating", q.MaxRating)
+	anyFilter(filter, "hash_bands", q.HashBands)
+	anyFilter(filter, "color_bins", q.ColorBins)
+
+	return filter
+}
+
+func requestFilter(q query.RequestQuery) bson.M {
+	filter := bson.M{}
+
+	equalFilter(filter, "kind", q.Kind)
+
+	if !q.ContentID.IsZero() {
+		filter["content_id"] = q.ContentID
+	}
+
+	return filter
+}
+
+func deadLetterFilter(q query.DeadLetterQuery) bson.M {
+	filter := bson.M{}
+
+	refFilter(filter, query.ByID(q.ID))
+	equalFilter(filter, "subject", q.Subject)
+
+	return filter
+}
+
+func userFilter(q query.UserQuery) bson.M {
+	filter := bson.M{}
+
+	equalFilter(filter, "username", q.Username)
+	equalFilter(filter, "email", q.Email)
+
+	return filter
+}
+
+func updateDocument(u query.Update) bson.M {
+	update := bson.M{}
+
+	if len(u.Set) > 0 {
+		set := bson.M{}
+		for field, value := range u.Set {
+			set[field] = value
+		}
+
+		update["$set"] = set
+	}
+
+	if len(u.Unset) > 0 {
+		unset := bson.M{}
+		for _, field := range u.Unset {
+			unset[field] = ""
+		}
+
+		update["$unset"] = unset
+	}
+
+	if len(u.Inc) > 0 {
+		inc := bson.M{}
+		for field, delta := range u.Inc {
+			inc[field] = delta
+		}
+
+		update["$inc"] = inc
+	}
+
+	return update
+}
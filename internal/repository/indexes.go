@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const OutboxRetention = 7 * 24 * time.Hour
+
+func (r *Repository) CreateIndexes(ctx context.Context) error {
+	slugIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "slug", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	// one index per pagination sort, mongo walks them in both directions
+	// so newest and oldest share the timestamp index.
+	newestIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}},
+	}
+
+	popularIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "views", Value: -1}, {Key: "_id", Value: -1}},
+	}
+
+	content := []mongo.IndexModel{slugIndex, newestIndex, popularIndex}
+
+	// backs the lookup of images with a similar perceptual hash.
+	hashIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "hash_bands", Value: 1}},
+	}
+
+	// backs the lookup of wallpapers by the colors of their palette.
+	colorIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "color_bins", Value: 1}},
+	}
+
+	indexes := map[*mongo.Collection][]mongo.IndexModel{
+		r.articlesColl:  append(slices.Clip(content), textIndex("title", "content")),
+		r.newsColl:      append(slices.Clip(content), textIndex("title", "content")),
+		r.cfuColl:       append(slices.Clip(content), textIndex("description"), hashIndex),
+		r.wallpaperColl: append(slices.Clip(content), hashIndex, colorIndex),
+		r.requestsColl: {
+			{
+				Keys: bson.D{{Key: "kind", Value: 1}, {Key: "content_id", Value: 1}, {Key: "censored_at", Value: 1}},
+			},
+		},
+		r.outboxColl: {
+			{
+				Keys: bson.D{{Key: "sent_at", Value: 1}, {Key: "created_at", Value: 1}},
+			},
+			// sent messages are only kept around for debugging.
+			{
+				Keys:    bson.D{{Key: "sent_at", Value: 1}},
+				Options: options.Index().SetExpireAfterSeconds(int32(OutboxRetention.Seconds())),
+			},
+		},
+		r.deadColl: {newestIndex},
+		r.userColl: {
+			{
+				Keys:    bson.D{{Key: "username", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				Keys:    bson.D{{Key: "email", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+	}
+
+	for coll, models := range indexes {
+		names, err := coll.Indexes().CreateMany(ctx, models)
+		if err != nil {
+			r.logger.Error("failed create indexes",
+				zap.String("collection", coll.Name()),
+				zap.Error(err))
+
+			return fmt.Errorf("create indexes for %s: %w", coll.Name(), err)
+		}
+
+		r.logger.Info("indexes ensured",
+			zap.String("collection", coll.Name()),
+			zap.Strings("indexes", names))
+	}
+
+	return nil
+}
+
+// textIndex backs search over fields, the first one weighs the most. Content
+// is written in several languages, so stemming and stop words are turned off
+// rather than guessed.
+func textIndex(fields ...string) mongo.IndexModel {
+	var (
+		keys    bson.D
+		weights bson.D
+	)
+
+	for i, field := range fields {
+		keys = append(keys, bson.E{Key: field, Value: "text"})
+
+		weight := 1
+		if i == 0 && len(fields) > 1 {
+			weight = 3
+		}
+
+		weights = append(weights, bson.E{Key: field, Value: weight})
+	}
+
+	return mongo.IndexModel{
+		Keys: keys,
+		Options: options.Index().
+			SetWeights(weights).
+			SetDefaultLanguage("none"),
+	}
+}
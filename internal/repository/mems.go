@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/pagination"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
@@ -13,6 +15,10 @@ import (
 func (r *Repository) CreateMem(ctx context.Context, mem *entity.Mem) error {
 	res, err := r.cfuColl.InsertOne(ctx, mem)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("create mem: %w", ErrAlreadyExists)
+		}
+
 		r.logger.Error("failed to create mem", zap.Error(err))
 		return fmt.Errorf("create mem: %w", ErrInsertFailed)
 	}
@@ -23,7 +29,12 @@ func (r *Repository) CreateMem(ctx context.Context, mem *entity.Mem) error {
 	return nil
 }
 
-func (r *Repository) UpdateMem(ctx context.Context, filter, update map[string]interface{}) error {
+func (r *Repository) UpdateMem(ctx context.Context, q query.MemQuery, u query.Update) error {
+	filter, update := memFilter(q), updateDocument(u)
+	if len(filter) == 0 || len(update) == 0 {
+		return ErrInvalidInput
+	}
+
 	r.logger.Debug("updating mem", zap.Any("filter", filter), zap.Any("update", update))
 	res, err := r.cfuColl.UpdateOne(ctx, filter, update)
 	if err != nil {
@@ -39,13 +50,14 @@ func (r *Repository) UpdateMem(ctx context.Context, filter, update map[string]in
 	return nil
 }
 
-func (r *Repository) GetMemsLimited(ctx context.Context, filter map[string]interface{}, limit int64) ([]entity.Mem, error) {
-	r.logger.Debug("fetching limited mems", zap.Any("filter", filter), zap.Int64("limit", limit))
+func (r *Repository) GetMemsPage(ctx context.Context, q query.MemQuery, page pagination.Request) ([]entity.Mem, error) {
+	filter := memFilter(q)
 
-	findOptions := options.Find()
-	findOptions.SetLimit(limit)
+	r.logger.Debug("fetching mems page", zap.Any("filter", filter), zap.String("sort", string(page.Sort)), zap.Int64("limit", page.Limit))
 
-	res, err := r.cfuColl.Find(ctx, filter, findOptions)
+	query, findOptions := pageQuery(filter, page)
+
+	res, err := r.cfuColl.Find(ctx, query, findOptions)
 	if err != nil {
 		r.logger.Error("failed to get limited mems", zap.Error(err))
 		return nil, fmt.Errorf("get limited mems: %w", ErrNotFound)
@@ -66,15 +78,16 @@ func (r *Repository) GetMemsLimited(ctx context.Context, filter map[string]inter
 		return nil, fmt.Errorf("parse mems: %w", ErrDecodeFailed)
 	}
 
-	if len(mems) == 0 {
-		return nil, ErrNoDocuments
-	}
-
 	r.logger.Info("mems fetched (limited)", zap.Int("length", len(mems)))
 	return mems, nil
 }
 
-func (r *Repository) DeleteMem(ctx context.Context, filter map[string]interface{}) error {
+func (r *Repository) DeleteMem(ctx context.Context, q query.MemQuery) error {
+	filter := memFilter(q)
+	if len(filter) == 0 {
+		return ErrInvalidInput
+	}
+
 	r.logger.Debug("deleting mem", zap.Any("filter", filter))
 	res, err := r.cfuColl.DeleteOne(ctx, filter)
 	if err != nil {
@@ -90,7 +103,12 @@ func (r *Repository) DeleteMem(ctx context.Context, filter map[string]interface{
 	return nil
 }
 
-func (r *Repository) GetMem(ctx context.Context, filter map[string]interface{}) (*entity.Mem, error) {
+func (r *Repository) GetMem(ctx context.Context, q query.MemQuery) (*entity.Mem, error) {
+	filter := memFilter(q)
+	if len(filter) == 0 {
+		return nil, ErrInvalidInput
+	}
+
 	r.logger.Debug("fetching single mem", zap.Any("filter", filter))
 
 	res := r.cfuColl.FindOne(ctx, filter)
@@ -112,3 +130,30 @@ func (r *Repository) GetMem(ctx context.Context, filter map[string]interface{})
 	r.logger.Info("mem fetched", zap.Any("mem", mem))
 	return &mem, nil
 }
+
+// GetSimilarMems returns at most limit mems sharing a hash band with q, the
+// caller measures how close they really are.
+func (r *Repository) GetSimilarMems(ctx context.Context, q query.MemQuery, limit int64) ([]entity.Mem, error) {
+	if len(q.HashBands) == 0 {
+		return nil, ErrInvalidInput
+	}
+
+	filter := memFilter(q)
+
+	r.logger.Debug("fetching similar mems", zap.Any("filter", filter), zap.Int64("limit", limit))
+
+	res, err := r.cfuColl.Find(ctx, filter, options.Find().SetLimit(limit))
+	if err != nil {
+		r.logger.Error("failed to get similar mems", zap.Error(err))
+		return nil, fmt.Errorf("get similar mems: %w", err)
+	}
+	defer res.Close(ctx)
+
+	var mems []entity.Mem
+	if err = res.All(ctx, &mems); err != nil {
+		r.logger.Error("failed to parse similar mems", zap.Error(err))
+		return nil, fmt.Errorf("parse similar mems: %w", ErrDecodeFailed)
+	}
+
+	return mems, nil
+}
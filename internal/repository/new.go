@@ -5,14 +5,19 @@ import (
 	"fmt"
 
 	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/pagination"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 )
 
 func (r *Repository) CreateNew(ctx context.Context, New *entity.New) error {
 	res, err := r.newsColl.InsertOne(ctx, New)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("create new: %w", ErrAlreadyExists)
+		}
+
 		r.logger.Error("failed create new", zap.Error(err))
 		return fmt.Errorf("create new: %w", ErrInsertFailed)
 	}
@@ -24,7 +29,12 @@ func (r *Repository) CreateNew(ctx context.Context, New *entity.New) error {
 	return nil
 }
 
-func (r *Repository) UpdateNew(ctx context.Context, filter, update map[string]interface{}) error {
+func (r *Repository) UpdateNew(ctx context.Context, q query.NewQuery, u query.Update) error {
+	filter, update := newFilter(q), updateDocument(u)
+	if len(filter) == 0 || len(update) == 0 {
+		return ErrInvalidInput
+	}
+
 	r.logger.Debug("updating new", zap.Any("filter", filter), zap.Any("update", update))
 
 	res, err := r.newsColl.UpdateOne(ctx, filter, update)
@@ -42,7 +52,12 @@ func (r *Repository) UpdateNew(ctx context.Context, filter, update map[string]in
 	return nil
 }
 
-func (r *Repository) GetNews(ctx context.Context, filter map[string]interface{}) (*entity.New, error) {
+func (r *Repository) GetNew(ctx context.Context, q query.NewQuery) (*entity.New, error) {
+	filter := newFilter(q)
+	if len(filter) == 0 {
+		return nil, ErrInvalidInput
+	}
+
 	r.logger.Debug("fetching single news", zap.Any("filter", filter))
 
 	res := r.newsColl.FindOne(ctx, filter)
@@ -65,13 +80,14 @@ func (r *Repository) GetNews(ctx context.Context, filter map[string]interface{})
 	return &n, nil
 }
 
-func (r *Repository) GetNewsLimited(ctx context.Context, filter map[string]interface{}, limit int64) ([]entity.New, error) {
-	r.logger.Debug("fetching limited news", zap.Any("filter", filter), zap.Int64("limit", limit))
+func (r *Repository) GetNewsPage(ctx context.Context, q query.NewQuery, page pagination.Request) ([]entity.New, error) {
+	filter := newFilter(q)
 
-	findOptions := options.Find()
-	findOptions.SetLimit(limit)
+	r.logger.Debug("fetching news page", zap.Any("filter", filter), zap.String("sort", string(page.Sort)), zap.Int64("limit", page.Limit))
 
-	res, err := r.newsColl.Find(ctx, filter, findOptions)
+	query, findOptions := pageQuery(filter, page)
+
+	res, err := r.newsColl.Find(ctx, query, findOptions)
 	if err != nil {
 		r.logger.Error("failed get limited news", zap.Error(err))
 		return nil, fmt.Errorf("get limited news: %w", ErrNotFound)
@@ -92,15 +108,16 @@ func (r *Repository) GetNewsLimited(ctx context.Context, filter map[string]inter
 		return nil, fmt.Errorf("parse news: %w", ErrDecodeFailed)
 	}
 
-	if len(news) == 0 {
-		return nil, ErrNoDocuments
-	}
-
 	r.logger.Info("news fetched (limited)", zap.Int("length", len(news)))
 	return news, nil
 }
 
-func (r *Repository) DeleteNew(ctx context.Context, filter map[string]interface{}) error {
+func (r *Repository) DeleteNew(ctx context.Context, q query.NewQuery) error {
+	filter := newFilter(q)
+	if len(filter) == 0 {
+		return ErrInvalidInput
+	}
+
 	r.logger.Debug("deleting new", zap.Any("filter", filter))
 
 	res, err := r.newsColl.DeleteOne(ctx, filter)
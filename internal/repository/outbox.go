@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// WithTransaction runs fn in a mongo transaction, repository calls made
+// with the context fn receives are part of it. Transactions need mongo to
+// run as a replica set.
+func (r *Repository) WithTransaction(ctx context.Context, fn func(context.Context) error) error {
+	session, err := r.client.StartSession()
+	if err != nil {
+		r.logger.Error("failed start session", zap.Error(err))
+		return fmt.Errorf("start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+
+	return err
+}
+
+func (r *Repository) CreateOutbox(ctx context.Context, msg *entity.OutboxMessage) error {
+	r.logger.Debug("writing outbox message", zap.String("subject", msg.Subject))
+
+	res, err := r.outboxColl.InsertOne(ctx, msg)
+	if err != nil {
+		r.logger.Error("failed write outbox message", zap.String("subject", msg.Subject), zap.Error(err))
+		return fmt.Errorf("create outbox message: %w", ErrInsertFailed)
+	}
+
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		msg.ID = id
+	}
+
+	return nil
+}
+
+// ClaimOutbox locks the oldest unsent message for lease, so relays of other
+// instances skip it meanwhile. It returns ErrNotFound when nothing is due.
+func (r *Repository) ClaimOutbox(ctx context.Context, lease time.Duration) (*entity.OutboxMessage, error) {
+	now := time.Now()
+
+	filter := bson.M{
+		"sent_at": nil,
+		"$or": bson.A{
+			bson.M{"locked_until": nil},
+			bson.M{"locked_until": bson.M{"$lt": now}},
+		},
+	}
+
+	update := bson.M{"$set": bson.M{"locked_until": now.Add(lease)}}
+
+	findOptions := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var msg entity.OutboxMessage
+
+	if err := r.outboxColl.FindOneAndUpdate(ctx, filter, update, findOptions).Decode(&msg); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+
+		r.logger.Error("failed claim outbox message", zap.Error(err))
+		return nil, fmt.Errorf("claim outbox message: %w", err)
+	}
+
+	return &msg, nil
+}
+
+func (r *Repository) MarkOutboxSent(ctx context.Context, id primitive.ObjectID) error {
+	update := bson.M{
+		"$set":   bson.M{"sent_at": time.Now(), "locked_until": nil},
+		"$unset": bson.M{"last_error": ""},
+	}
+
+	if _, err := r.outboxColl.UpdateByID(ctx, id, update); err != nil {
+		r.logger.Error("failed mark outbox message sent", zap.String("id", id.Hex()), zap.Error(err))
+		return fmt.Errorf("mark outbox message sent: %w", ErrUpdateFailed)
+	}
+
+	return nil
+}
+
+// MarkOutboxFailed releases the message for another try after retryIn.
+func (r *Repository) MarkOutboxFailed(ctx context.Context, id primitive.ObjectID, cause string, retryIn time.Duration) error {
+	update := bson.M{
+		"$set": bson.M{"last_error": cause, "locked_until": time.Now().Add(retryIn)},
+		"$inc": bson.M{"attempts": 1},
+	}
+
+	if _, err := r.outboxColl.UpdateByID(ctx, id, update); err != nil {
+		r.logger.Error("failed mark outbox message failed", zap.String("id", id.Hex()), zap.Error(err))
+		return fmt.Errorf("mark outbox message failed: %w", ErrUpdateFailed)
+	}
+
+	return nil
+}
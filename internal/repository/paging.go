@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"github.com/osamikoyo/dark-fantasy-land/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// pageQuery extends filter with the keyset condition of req and returns the
+// options for fetching one document more than the page holds.
+func pageQuery(filter bson.M, req pagination.Request) (bson.M, *options.FindOptions) {
+	field, direction := "timestamp", -1
+
+	switch req.Sort {
+	case pagination.SortOldest:
+		direction = 1
+	case pagination.SortPopular:
+		field = "views"
+	}
+
+	query := filter
+
+	if req.After != nil {
+		var value interface{} = req.After.Timestamp
+		if field == "views" {
+			value = req.After.Views
+		}
+
+		cmp := "$lt"
+		if direction == 1 {
+			cmp = "$gt"
+		}
+
+		keyset := bson.M{"$or": bson.A{
+			bson.M{field: bson.M{cmp: value}},
+			bson.M{field: value, "_id": bson.M{cmp: req.After.ID}},
+		}}
+
+		query = bson.M{"$and": bson.A{query, keyset}}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(req.Limit + 1)
+
+	return query, opts
+}
@@ -21,11 +21,15 @@ var (
 )
 
 type Repository struct {
+	client        *mongo.Client
 	articlesColl  *mongo.Collection
 	newsColl      *mongo.Collection
 	cfuColl       *mongo.Collection
 	wallpaperColl *mongo.Collection
 	userColl      *mongo.Collection
+	requestsColl  *mongo.Collection
+	outboxColl    *mongo.Collection
+	deadColl      *mongo.Collection
 	logger        *logger.Logger
 }
 
@@ -50,11 +54,36 @@ func NewRepository(db *mongo.Database, logger *logger.Logger) (*Repository, erro
 		return nil, fmt.Errorf("failed get collection for wallpaper: %w", ErrNotFound)
 	}
 
+	users := db.Collection("users")
+	if users == nil {
+		return nil, fmt.Errorf("failed get collection for users: %w", ErrNotFound)
+	}
+
+	requests := db.Collection("requests")
+	if requests == nil {
+		return nil, fmt.Errorf("failed get collection for requests: %w", ErrNotFound)
+	}
+
+	outbox := db.Collection("outbox")
+	if outbox == nil {
+		return nil, fmt.Errorf("failed get collection for outbox: %w", ErrNotFound)
+	}
+
+	dead := db.Collection("dead_letters")
+	if dead == nil {
+		return nil, fmt.Errorf("failed get collection for dead letters: %w", ErrNotFound)
+	}
+
 	return &Repository{
+		client:        db.Client(),
 		articlesColl:  articles,
 		newsColl:      news,
 		cfuColl:       cfu,
 		wallpaperColl: wallpaper,
+		userColl:      users,
+		requestsColl:  requests,
+		outboxColl:    outbox,
+		deadColl:      dead,
 		logger:        logger,
 	}, nil
 }
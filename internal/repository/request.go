@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+func (r *Repository) CreateRequest(ctx context.Context, req *entity.Request) error {
+	r.logger.Debug("recording moderation request", zap.Any("request", req))
+
+	res, err := r.requestsColl.InsertOne(ctx, req)
+	if err != nil {
+		r.logger.Error("failed record moderation request", zap.String("kind", req.Kind), zap.Error(err))
+		return fmt.Errorf("create request: %w", ErrInsertFailed)
+	}
+
+	r.logger.Info("moderation request recorded", zap.String("inserted_id", fmt.Sprintf("%v", res.InsertedID)))
+	return nil
+}
+
+// GetRequests returns the verdicts matching q, oldest first.
+func (r *Repository) GetRequests(ctx context.Context, q query.RequestQuery) ([]entity.Request, error) {
+	filter := requestFilter(q)
+	if len(filter) == 0 {
+		return nil, ErrInvalidInput
+	}
+
+	r.logger.Debug("fetching moderation requests", zap.Any("filter", filter))
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "censored_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	res, err := r.requestsColl.Find(ctx, filter, findOptions)
+	if err != nil {
+		r.logger.Error("failed fetch moderation requests", zap.Any("filter", filter), zap.Error(err))
+		return nil, fmt.Errorf("get requests: %w", err)
+	}
+	defer res.Close(ctx)
+
+	requests := []entity.Request{}
+	if err = res.All(ctx, &requests); err != nil {
+		r.logger.Warn("failed decode moderation requests", zap.Error(err))
+		return nil, fmt.Errorf("decode requests: %w", ErrDecodeFailed)
+	}
+
+	return requests, nil
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// searchDoc is the shape shared by every searchable collection, fields a
+// collection does not have are left empty.
+type searchDoc struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	Slug        string             `bson:"slug"`
+	Title       string             `bson:"title"`
+	Content     string             `bson:"content"`
+	Description string             `bson:"description"`
+	Topics      []string           `bson:"topics"`
+	Topic       string             `bson:"topic"`
+	Timestamp   time.Time          `bson:"timestamp"`
+	Score       float64            `bson:"score"`
+}
+
+type searchTarget struct {
+	kind        string
+	coll        *mongo.Collection
+	topicField  string
+	ratingField string
+}
+
+func (r *Repository) searchTargets() []searchTarget {
+	return []searchTarget{
+		{kind: query.SearchArticles, coll: r.articlesColl, topicField: "topics", ratingField: "rating"},
+		{kind: query.SearchNews, coll: r.newsColl, topicField: "topic", ratingField: "censor"},
+		{kind: query.SearchMems, coll: r.cfuColl, topicField: "topics", ratingField: "rating"},
+	}
+}
+
+// Search runs q against the text index of every requested type and merges
+// the hits by relevance. Facets count matches of every type, requested or
+// not, so clients can offer to widen the search.
+func (r *Repository) Search(ctx context.Context, q query.SearchQuery) (*entity.SearchResult, error) {
+	if q.Text == "" || q.Limit < 1 {
+		return nil, ErrInvalidInput
+	}
+
+	r.logger.Debug("searching content", zap.String("text", q.Text), zap.Strings("types", q.Types))
+
+	result := &entity.SearchResult{
+		Hits:   []entity.SearchHit{},
+		Facets: make(map[string]int64),
+	}
+
+	for _, target := range r.searchTargets() {
+		// search only serves the public, content still under moderation
+		// never shows up in it.
+		filter := bson.M{"$text": bson.M{"$search": q.Text}}
+		statusFilter(filter, []string{entity.StatusApproved})
+		ratingFilter(filter, target.ratingField, &q.MaxRating)
+		if len(q.Topics) > 0 {
+			filter[target.topicField] = bson.M{"$in": q.Topics}
+		}
+
+		count, err := target.coll.CountDocuments(ctx, filter)
+		if err != nil {
+			r.logger.Error("failed count search matches", zap.String("type", target.kind), zap.Error(err))
+			return nil, fmt.Errorf("count %s matches: %w", target.kind, err)
+		}
+
+		result.Facets[target.kind] = count
+
+		if count == 0 || !q.Has(target.kind) {
+			continue
+		}
+
+		hits, err := r.searchCollection(ctx, target, filter, q.Limit)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Hits = append(result.Hits, hits...)
+	}
+
+	slices.SortStableFunc(result.Hits, func(a, b entity.SearchHit) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		default:
+			return b.Timestamp.Compare(a.Timestamp)
+		}
+	})
+
+	if int64(len(result.Hits)) > q.Limit {
+		result.Hits = result.Hits[:q.Limit]
+	}
+
+	return result, nil
+}
+
+func (r *Repository) searchCollection(ctx context.Context, target searchTarget, filter bson.M, limit int64) ([]entity.SearchHit, error) {
+	score := bson.M{"$meta": "textScore"}
+
+	findOptions := options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}}).
+		SetLimit(limit)
+
+	res, err := target.coll.Find(ctx, filter, findOptions)
+	if err != nil {
+		r.logger.Error("failed search collection", zap.String("type", target.kind), zap.Error(err))
+		return nil, fmt.Errorf("search %s: %w", target.kind, err)
+	}
+	defer res.Close(ctx)
+
+	var hits []entity.SearchHit
+	for res.Next(ctx) {
+		var doc searchDoc
+		if err = res.Decode(&doc); err != nil {
+			r.logger.Warn("failed decode search hit", zap.String("type", target.kind), zap.Error(err))
+			return nil, fmt.Errorf("decode %s hit: %w", target.kind, ErrDecodeFailed)
+		}
+
+		hit := entity.SearchHit{
+			Type:      target.kind,
+			ID:        doc.ID,
+			Slug:      doc.Slug,
+			Title:     doc.Title,
+			Topics:    doc.Topics,
+			Timestamp: doc.Timestamp,
+			Score:     doc.Score,
+			Text:      doc.Content,
+		}
+
+		if doc.Topic != "" {
+			hit.Topics = []string{doc.Topic}
+		}
+
+		if target.kind == query.SearchMems {
+			hit.Text = doc.Description
+		}
+
+		hits = append(hits, hit)
+	}
+
+	if err = res.Err(); err != nil {
+		r.logger.Error("error from search response", zap.String("type", target.kind), zap.Error(err))
+		return nil, fmt.Errorf("parse %s hits: %w", target.kind, ErrDecodeFailed)
+	}
+
+	return hits, nil
+}
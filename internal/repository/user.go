@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+func (r *Repository) CreateUser(ctx context.Context, user *entity.User) error {
+	res, err := r.userColl.InsertOne(ctx, user)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			r.logger.Warn("user already exists", zap.String("username", user.Username))
+			return fmt.Errorf("create user: %w", ErrAlreadyExists)
+		}
+
+		r.logger.Error("failed create user", zap.String("username", user.Username), zap.Error(err))
+		return fmt.Errorf("create user: %w", ErrInsertFailed)
+	}
+
+	r.logger.Info("user created",
+		zap.String("username", user.Username),
+		zap.String("inserted_id", fmt.Sprintf("%v", res.InsertedID)))
+
+	return nil
+}
+
+func (r *Repository) UpdateUser(ctx context.Context, q query.UserQuery, u query.Update) error {
+	filter, update := userFilter(q), updateDocument(u)
+	if len(filter) == 0 || len(update) == 0 {
+		return ErrInvalidInput
+	}
+
+	r.logger.Debug("updating user", zap.Any("filter", filter))
+
+	res, err := r.userColl.UpdateOne(ctx, filter, update)
+	if err != nil {
+		r.logger.Error("failed update user", zap.Error(err))
+		return fmt.Errorf("update user: %w", ErrUpdateFailed)
+	}
+
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	r.logger.Info("user updated", zap.Int64("matched_count", res.MatchedCount))
+	return nil
+}
+
+func (r *Repository) DeleteUser(ctx context.Context, q query.UserQuery) error {
+	filter := userFilter(q)
+	if len(filter) == 0 {
+		return ErrInvalidInput
+	}
+
+	r.logger.Debug("deleting user", zap.Any("filter", filter))
+
+	res, err := r.userColl.DeleteOne(ctx, filter)
+	if err != nil {
+		r.logger.Error("failed delete user", zap.Error(err))
+		return fmt.Errorf("delete user: %w", ErrDeleteFailed)
+	}
+
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	r.logger.Info("user deleted", zap.Any("filter", filter))
+	return nil
+}
+
+func (r *Repository) GetUser(ctx context.Context, q query.UserQuery) (*entity.User, error) {
+	filter := userFilter(q)
+	if len(filter) == 0 {
+		return nil, ErrInvalidInput
+	}
+
+	r.logger.Debug("fetching single user", zap.Any("filter", filter))
+
+	res := r.userColl.FindOne(ctx, filter)
+	if res.Err() != nil {
+		if res.Err() == mongo.ErrNoDocuments {
+			r.logger.Warn("user not found", zap.Any("filter", filter))
+			return nil, ErrNotFound
+		}
+		r.logger.Error("failed to get user", zap.Error(res.Err()))
+		return nil, fmt.Errorf("get user: %w", res.Err())
+	}
+
+	var user entity.User
+	if err := res.Decode(&user); err != nil {
+		r.logger.Warn("failed decode user", zap.Error(err))
+		return nil, fmt.Errorf("decode user: %w", ErrDecodeFailed)
+	}
+
+	r.logger.Info("user fetched", zap.String("username", user.Username))
+	return &user, nil
+}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 
 	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
@@ -13,6 +16,10 @@ import (
 func (r *Repository) CreateWallpaper(ctx context.Context, wallpaper *entity.Wallpaper) error {
 	res, err := r.wallpaperColl.InsertOne(ctx, wallpaper)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("create wallpaper: %w", ErrAlreadyExists)
+		}
+
 		r.logger.Error("failed to create wallpaper", zap.Error(err))
 		return fmt.Errorf("create wallpaper: %w", ErrInsertFailed)
 	}
@@ -23,7 +30,12 @@ func (r *Repository) CreateWallpaper(ctx context.Context, wallpaper *entity.Wall
 	return nil
 }
 
-func (r *Repository) UpdateWallpaper(ctx context.Context, filter, update map[string]interface{}) error {
+func (r *Repository) UpdateWallpaper(ctx context.Context, q query.WallpaperQuery, u query.Update) error {
+	filter, update := wallpaperFilter(q), updateDocument(u)
+	if len(filter) == 0 || len(update) == 0 {
+		return ErrInvalidInput
+	}
+
 	r.logger.Debug("updating wallpaper", zap.Any("filter", filter), zap.Any("update", update))
 	res, err := r.wallpaperColl.UpdateOne(ctx, filter, update)
 	if err != nil {
@@ -39,7 +51,12 @@ func (r *Repository) UpdateWallpaper(ctx context.Context, filter, update map[str
 	return nil
 }
 
-func (r *Repository) GetWallpaper(ctx context.Context, filter map[string]interface{}) (*entity.Wallpaper, error) {
+func (r *Repository) GetWallpaper(ctx context.Context, q query.WallpaperQuery) (*entity.Wallpaper, error) {
+	filter := wallpaperFilter(q)
+	if len(filter) == 0 {
+		return nil, ErrInvalidInput
+	}
+
 	r.logger.Debug("fetching single wallpaper", zap.Any("filter", filter))
 
 	res := r.wallpaperColl.FindOne(ctx, filter)
@@ -62,13 +79,14 @@ func (r *Repository) GetWallpaper(ctx context.Context, filter map[string]interfa
 	return &wallpaper, nil
 }
 
-func (r *Repository) GetWallpapersLimited(ctx context.Context, filter map[string]interface{}, limit int64) ([]entity.Wallpaper, error) {
-	r.logger.Debug("fetching limited wallpapers", zap.Any("filter", filter), zap.Int64("limit", limit))
+func (r *Repository) GetWallpapersPage(ctx context.Context, q query.WallpaperQuery, page pagination.Request) ([]entity.Wallpaper, error) {
+	filter := wallpaperFilter(q)
 
-	findOptions := options.Find()
-	findOptions.SetLimit(limit)
+	r.logger.Debug("fetching wallpapers page", zap.Any("filter", filter), zap.String("sort", string(page.Sort)), zap.Int64("limit", page.Limit))
 
-	res, err := r.wallpaperColl.Find(ctx, filter, findOptions)
+	query, findOptions := pageQuery(filter, page)
+
+	res, err := r.wallpaperColl.Find(ctx, query, findOptions)
 	if err != nil {
 		r.logger.Error("failed to get limited wallpapers", zap.Error(err))
 		return nil, fmt.Errorf("get limited wallpapers: %w", ErrNotFound)
@@ -89,15 +107,16 @@ func (r *Repository) GetWallpapersLimited(ctx context.Context, filter map[string
 		return nil, fmt.Errorf("parse wallpapers: %w", ErrDecodeFailed)
 	}
 
-	if len(wallpapers) == 0 {
-		return nil, ErrNoDocuments
-	}
-
 	r.logger.Info("wallpapers fetched (limited)", zap.Int("length", len(wallpapers)))
 	return wallpapers, nil
 }
 
-func (r *Repository) DeleteWallpaper(ctx context.Context, filter map[string]interface{}) error {
+func (r *Repository) DeleteWallpaper(ctx context.Context, q query.WallpaperQuery) error {
+	filter := wallpaperFilter(q)
+	if len(filter) == 0 {
+		return ErrInvalidInput
+	}
+
 	r.logger.Debug("deleting wallpaper", zap.Any("filter", filter))
 	res, err := r.wallpaperColl.DeleteOne(ctx, filter)
 	if err != nil {
@@ -112,3 +131,63 @@ func (r *Repository) DeleteWallpaper(ctx context.Context, filter map[string]inte
 	r.logger.Info("wallpaper deleted", zap.Any("filter", filter))
 	return nil
 }
+
+// GetSimilarWallpapers returns at most limit wallpapers sharing a hash band
+// with q, the caller measures how close they really are.
+func (r *Repository) GetSimilarWallpapers(ctx context.Context, q query.WallpaperQuery, limit int64) ([]entity.Wallpaper, error) {
+	if len(q.HashBands) == 0 {
+		return nil, ErrInvalidInput
+	}
+
+	filter := wallpaperFilter(q)
+
+	r.logger.Debug("fetching similar wallpapers", zap.Any("filter", filter), zap.Int64("limit", limit))
+
+	res, err := r.wallpaperColl.Find(ctx, filter, options.Find().SetLimit(limit))
+	if err != nil {
+		r.logger.Error("failed to get similar wallpapers", zap.Error(err))
+		return nil, fmt.Errorf("get similar wallpapers: %w", err)
+	}
+	defer res.Close(ctx)
+
+	var wallpapers []entity.Wallpaper
+	if err = res.All(ctx, &wallpapers); err != nil {
+		r.logger.Error("failed to parse similar wallpapers", zap.Error(err))
+		return nil, fmt.Errorf("parse similar wallpapers: %w", ErrDecodeFailed)
+	}
+
+	return wallpapers, nil
+}
+
+// GetColoredWallpapers returns at most limit wallpapers with a palette color
+// in one of the bins of q, the caller ranks them by how close they are.
+func (r *Repository) GetColoredWallpapers(ctx context.Context, q query.WallpaperQuery, limit int64) ([]entity.Wallpaper, error) {
+	if len(q.ColorBins) == 0 {
+		return nil, ErrInvalidInput
+	}
+
+	filter := wallpaperFilter(q)
+
+	r.logger.Debug("fetching colored wallpapers", zap.Any("filter", filter), zap.Int64("limit", limit))
+
+	// the newest candidates win when there are more than limit, in the
+	// same order every time, so pages ranked from them stay stable.
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(limit)
+
+	res, err := r.wallpaperColl.Find(ctx, filter, findOptions)
+	if err != nil {
+		r.logger.Error("failed to get colored wallpapers", zap.Error(err))
+		return nil, fmt.Errorf("get colored wallpapers: %w", err)
+	}
+	defer res.Close(ctx)
+
+	var wallpapers []entity.Wallpaper
+	if err = res.All(ctx, &wallpapers); err != nil {
+		r.logger.Error("failed to parse colored wallpapers", zap.Error(err))
+		return nil, fmt.Errorf("parse colored wallpapers: %w", ErrDecodeFailed)
+	}
+
+	return wallpapers, nil
+}
@@ -1,24 +1,34 @@
 package service
 
 import (
+	"context"
 	"errors"
-	"sync"
-	"time"
 
 	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
 	"github.com/osamikoyo/dark-fantasy-land/internal/repository"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/pagination"
 	"github.com/osamikoyo/dark-fantasy-land/pkg/retrier"
 )
 
 func (s *Service) CreateArticle(article *entity.Article) error {
-	if article == nil {
+	if article == nil || !entity.ValidRating(article.Rating) {
 		return ErrInvalidInput
 	}
 
 	ctx, cancel := s.context()
 	defer cancel()
 
-	if err := retrier.Do(3, 2*time.Second, func() error {
+	if err := s.authorizeAuthor(ctx, article.Author, ActionCreateContent); err != nil {
+		return err
+	}
+
+	article.Slug = identify(&article.ID, article.Title, "article")
+	article.Status = entity.StatusPending
+	article.Views = 0
+	article.Revision = 0
+
+	if err := s.createWithOutbox(ctx, "articles", article, func(ctx context.Context) error {
 		return s.repo.CreateArticle(ctx, article)
 	}); err != nil {
 		if errors.Is(err, repository.ErrAlreadyExists) {
@@ -28,10 +38,6 @@ func (s *Service) CreateArticle(article *entity.Article) error {
 		return ErrRepositoryFailed
 	}
 
-	if err := s.sendToCensor(article, "articles"); err != nil {
-		return err
-	}
-
 	if err := retrier.Do(RetrierAttemps, RetrierDuration, func() error {
 		return s.casher.AddArticleToCash(ctx, article)
 	}); err != nil {
@@ -41,48 +47,68 @@ func (s *Service) CreateArticle(article *entity.Article) error {
 	return nil
 }
 
-func (s *Service) UpdateArticle(author, title string, update map[string]interface{}) error {
-	if author == "" || title == "" {
+func (s *Service) UpdateArticle(actor *entity.User, ref string, patch map[string]interface{}) error {
+	if ref == "" {
 		return ErrInvalidInput
 	}
 
 	ctx, cancel := s.context()
 	defer cancel()
 
-	filter := make(map[string]interface{})
-	filter["author"] = author
-	filter["title"] = title
+	article, err := s.getArticle(ctx, ref)
+	if err != nil {
+		return err
+	}
 
-	if err := s.repo.UpdateArticle(ctx, filter, update); err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return ErrNotFound
-		}
+	if err = authorize(actor, ActionEditAny, article.Author); err != nil {
+		return err
+	}
 
-		return ErrRepositoryFailed
+	update, err := mergePatch(patch, articlePatchFields)
+	if err != nil {
+		return err
 	}
 
-	for key, value := range update {
-		if err := s.casher.UpdateArticleInCash(ctx, author, title, key, value); err != nil {
-			return ErrCacheSetFailed
-		}
+	if err = s.applyPatch(ctx, patchTarget{
+		subject: "articles",
+		status:  article.Status,
+		write: func(ctx context.Context, statuses []string, u query.Update) error {
+			return s.repo.UpdateArticle(ctx, query.ArticleQuery{Ref: query.ByID(article.ID), Statuses: statuses}, u)
+		},
+		reload: func(ctx context.Context) (interface{}, error) {
+			return s.repo.GetArticle(ctx, query.ArticleQuery{Ref: query.ByID(article.ID)})
+		},
+	}, update, resubmits(patch, articlePatchFields)); err != nil {
+		return err
+	}
+
+	// slugs survive renames, so dropping the cached copy is enough to keep
+	// both the id and the slug lookups fresh.
+	if err = s.casher.DeleteArticleFromCash(ctx, article); err != nil {
+		return ErrCacheDelFailed
 	}
 
 	return nil
 }
 
-func (s *Service) DeleteArticle(author, title string) error {
-	if author == "" || title == "" {
+func (s *Service) DeleteArticle(actor *entity.User, ref string) error {
+	if ref == "" {
 		return ErrInvalidInput
 	}
 
 	ctx, cancel := s.context()
 	defer cancel()
 
-	filter := make(map[string]interface{})
-	filter["author"] = author
-	filter["title"] = title
+	article, err := s.getArticle(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	if err = authorize(actor, ActionEditAny, article.Author); err != nil {
+		return err
+	}
 
-	if err := s.repo.DeleteArticle(ctx, filter); err != nil {
+	if err = s.repo.DeleteArticle(ctx, query.ArticleQuery{Ref: query.ByID(article.ID)}); err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return ErrNotFound
 		}
@@ -90,78 +116,75 @@ func (s *Service) DeleteArticle(author, title string) error {
 		return ErrRepositoryFailed
 	}
 
-	if err := s.casher.DeleteArticleFromCash(ctx, author, title); err != nil {
+	if err = s.casher.DeleteArticleFromCash(ctx, article); err != nil {
 		return ErrCacheDelFailed
 	}
 
 	return nil
 }
 
-func (s *Service) GetOneArticle(author, title string) (*entity.Article, error) {
-	if author == "" || title == "" {
+func (s *Service) GetOneArticle(viewer *entity.User, ref string, maxRating uint8) (*entity.Article, error) {
+	if ref == "" {
 		return nil, ErrInvalidInput
 	}
 
 	ctx, cancel := s.context()
 	defer cancel()
 
-	filter := make(map[string]interface{})
-	filter["author"] = author
-	filter["title"] = title
+	article, err := s.getArticle(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
 
-	var (
-		articleChan = make(chan *entity.Article, 1)
-		errChan     = make(chan error, 2)
-		wg          sync.WaitGroup
-	)
+	if err = visible(viewer, article.Status, article.Author); err != nil {
+		return nil, err
+	}
 
-	wg.Add(1)
+	if article.Rating > maxRating {
+		return nil, ErrAboveRating
+	}
 
-	go func() {
-		defer wg.Done()
+	// views only feed the popular sort, losing one is not worth failing
+	// the read for.
+	_ = s.repo.UpdateArticle(ctx, query.ArticleQuery{Ref: query.ByID(article.ID)}, query.Update{Inc: map[string]int64{"views": 1}})
 
-		article, err := s.repo.GetArticle(ctx, filter)
-		if err != nil {
-			errChan <- err
-		}
-		articleChan <- article
-	}()
+	return article, nil
+}
 
-	go func() {
-		defer wg.Done()
+func (s *Service) getArticle(ctx context.Context, ref string) (*entity.Article, error) {
+	if article, err := s.casher.GetArticleFromCash(ctx, ref); err == nil {
+		return article, nil
+	}
 
-		article, err := s.casher.GetArticleFromCash(ctx, author, title)
-		if err != nil {
-			errChan <- err
+	article, err := s.repo.GetArticle(ctx, query.ArticleQuery{Ref: query.ParseRef(ref)})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
 		}
 
-		articleChan <- article
-	}()
+		return nil, ErrRepositoryFailed
+	}
 
-	errCount := 0
+	// the cache is best effort on reads, a failed fill only costs the next
+	// lookup another round trip to mongo.
+	_ = s.casher.AddArticleToCash(ctx, article)
 
-	select {
-	case article := <-articleChan:
-		return article, nil
-	case err := <-errChan:
-		errCount++
-		if errCount < 1 {
-			if errors.Is(err, repository.ErrNotFound) {
-				return nil, ErrNotFound
-			}
-
-			return nil, err
-		}
-	}
+	return article, nil
+}
+
+// GetMoreArticles lists approved content only, the rest is reachable through
+// the moderation queue.
+func (s *Service) GetMoreArticles(q query.ArticleQuery, page pagination.Request) (*pagination.Page[entity.Article], error) {
+	q.Statuses = []string{entity.StatusApproved}
 
-	return nil, ErrInternal
+	return s.articlesPage(q, page)
 }
 
-func (s *Service) GetMoreArticles(filter map[string]interface{}) ([]entity.Article, error) {
+func (s *Service) articlesPage(q query.ArticleQuery, page pagination.Request) (*pagination.Page[entity.Article], error) {
 	ctx, cancel := s.context()
 	defer cancel()
 
-	articles, err := s.repo.GetArticlesLimited(ctx, filter, Limit)
+	articles, err := s.repo.GetArticlesPage(ctx, q, page)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return nil, ErrNotFound
@@ -170,5 +193,7 @@ func (s *Service) GetMoreArticles(filter map[string]interface{}) ([]entity.Artic
 		return nil, ErrRepositoryFailed
 	}
 
-	return articles, nil
+	return pagination.Build(articles, page, func(article entity.Article) pagination.Cursor {
+		return pagination.Cursor{Timestamp: article.Timestamp, Views: article.Views, ID: article.ID}
+	}), nil
 }
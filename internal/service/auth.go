@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"github.com/osamikoyo/dark-fantasy-land/internal/repository"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/token"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (s *Service) Login(username, password string) (*token.Pair, error) {
+	username = strings.ToLower(strings.TrimSpace(username))
+	if username == "" || password == "" {
+		return nil, ErrInvalidInput
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+
+	// the cached user carries no password hash, it is read from the db.
+	user, err := s.repo.GetUser(ctx, query.UserQuery{Username: username})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUnauthorized
+		}
+
+		return nil, ErrRepositoryFailed
+	}
+
+	if err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	pair, err := s.tokens.Issue(user.Username)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	return pair, nil
+}
+
+// Refresh rotates a refresh token, the presented one can not be used again.
+func (s *Service) Refresh(refreshToken string) (*token.Pair, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	claims, err := s.checkToken(ctx, refreshToken, token.KindRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = s.getUser(ctx, claims.Subject); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrUnauthorized
+		}
+
+		return nil, err
+	}
+
+	if err = s.revoke(ctx, claims); err != nil {
+		return nil, err
+	}
+
+	pair, err := s.tokens.Issue(claims.Subject)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	return pair, nil
+}
+
+func (s *Service) Logout(accessToken, refreshToken string) error {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	access, err := s.checkToken(ctx, accessToken, token.KindAccess)
+	if err != nil {
+		return err
+	}
+
+	if err = s.revoke(ctx, access); err != nil {
+		return err
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	refresh, err := s.checkToken(ctx, refreshToken, token.KindRefresh)
+	if err != nil {
+		return err
+	}
+
+	if refresh.Subject != access.Subject {
+		return ErrUnauthorized
+	}
+
+	return s.revoke(ctx, refresh)
+}
+
+// Authenticate resolves an access token into the user it was issued to.
+func (s *Service) Authenticate(accessToken string) (*entity.User, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	claims, err := s.checkToken(ctx, accessToken, token.KindAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.getUser(ctx, claims.Subject)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrUnauthorized
+		}
+
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *Service) checkToken(ctx context.Context, raw, kind string) (*token.Claims, error) {
+	if raw == "" {
+		return nil, ErrUnauthorized
+	}
+
+	claims, err := s.tokens.Parse(raw, kind)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	revoked, err := s.casher.IsTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return nil, ErrCacheGetFailed
+	}
+
+	if revoked {
+		return nil, ErrUnauthorized
+	}
+
+	return claims, nil
+}
+
+// revoke burns the token of claims. A token some other request revoked
+// first is refused, which keeps a refresh token from being rotated twice.
+func (s *Service) revoke(ctx context.Context, claims *token.Claims) error {
+	revoked, err := s.casher.RevokeToken(ctx, claims.ID, time.Until(claims.ExpiresAt.Time))
+	if err != nil {
+		return ErrCacheSetFailed
+	}
+
+	if !revoked {
+		return ErrUnauthorized
+	}
+
+	return nil
+}
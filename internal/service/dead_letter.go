@@ -0,0 +1,148 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"github.com/osamikoyo/dark-fantasy-land/internal/repository"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RecordDeadLetter keeps a message the consumer gave up on.
+func (s *Service) RecordDeadLetter(letter *entity.DeadLetter) error {
+	if letter == nil || letter.Subject == "" {
+		return ErrInvalidInput
+	}
+
+	if letter.Timestamp.IsZero() {
+		letter.Timestamp = time.Now()
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+
+	if err := s.repo.CreateDeadLetter(ctx, letter); err != nil {
+		return ErrRepositoryFailed
+	}
+
+	return nil
+}
+
+// DeadLetters pages through dead letters, optionally of one subject. Dead
+// letters have no views, so only the time based sorts apply.
+func (s *Service) DeadLetters(actor *entity.User, subject string, page pagination.Request) (*pagination.Page[entity.DeadLetter], error) {
+	if err := authorize(actor, ActionReplayMessages, ""); err != nil {
+		return nil, err
+	}
+
+	if page.Sort == pagination.SortPopular {
+		return nil, ErrInvalidInput
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+
+	letters, err := s.repo.GetDeadLettersPage(ctx, query.DeadLetterQuery{Subject: subject}, page)
+	if err != nil {
+		return nil, ErrRepositoryFailed
+	}
+
+	return pagination.Build(letters, page, func(letter entity.DeadLetter) pagination.Cursor {
+		return pagination.Cursor{Timestamp: letter.Timestamp, ID: letter.ID}
+	}), nil
+}
+
+func (s *Service) DeadLetter(actor *entity.User, id string) (*entity.DeadLetter, error) {
+	if err := authorize(actor, ActionReplayMessages, ""); err != nil {
+		return nil, err
+	}
+
+	q, err := deadLetterQuery(id)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+
+	letter, err := s.repo.GetDeadLetter(ctx, q)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+
+		return nil, ErrRepositoryFailed
+	}
+
+	return letter, nil
+}
+
+// ReplayDeadLetter publishes the original payload to its subject again and
+// forgets the dead letter once the broker has it. If it fails again, the
+// consumer files a new one.
+func (s *Service) ReplayDeadLetter(actor *entity.User, id string) error {
+	letter, err := s.DeadLetter(actor, id)
+	if err != nil {
+		return err
+	}
+
+	// no id, a replay is meant to be delivered again.
+	if err = s.sender.SendToCensor(letter.Subject, "", json.RawMessage(letter.Payload)); err != nil {
+		return ErrInternal
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+
+	if _, err = s.repo.DeleteDeadLetters(ctx, query.DeadLetterQuery{ID: letter.ID}); err != nil {
+		return ErrRepositoryFailed
+	}
+
+	return nil
+}
+
+// PurgeDeadLetters deletes one dead letter by id, all of a subject, or all
+// of them when both are empty.
+func (s *Service) PurgeDeadLetters(actor *entity.User, id, subject string) (int64, error) {
+	if err := authorize(actor, ActionReplayMessages, ""); err != nil {
+		return 0, err
+	}
+
+	q := query.DeadLetterQuery{Subject: subject}
+
+	if id != "" {
+		byID, err := deadLetterQuery(id)
+		if err != nil {
+			return 0, err
+		}
+
+		q.ID = byID.ID
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+
+	deleted, err := s.repo.DeleteDeadLetters(ctx, q)
+	if err != nil {
+		return 0, ErrRepositoryFailed
+	}
+
+	if id != "" && deleted == 0 {
+		return 0, ErrNotFound
+	}
+
+	return deleted, nil
+}
+
+func deadLetterQuery(id string) (query.DeadLetterQuery, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return query.DeadLetterQuery{}, ErrInvalidInput
+	}
+
+	return query.DeadLetterQuery{ID: oid}, nil
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/config"
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/imaging"
+)
+
+const (
+	// SimilarCandidates caps the documents one hash lookup fetches.
+	SimilarCandidates = 500
+
+	DefaultSimilarLimit = 20
+	MaxSimilarLimit     = 100
+)
+
+// DuplicateError refuses an upload that repeats existing content, it
+// matches ErrDuplicate and tells which content came first.
+type DuplicateError struct {
+	Original entity.Duplicate
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("%s, see %s %s", ErrDuplicate, e.Original.Kind, e.Original.Slug)
+}
+
+func (e *DuplicateError) Is(target error) bool {
+	return target == ErrDuplicate
+}
+
+// fingerprint checks the perceptual hash of an upload by author against
+// the mems and wallpapers it may see, see closestImage. It returns the bands to store next to the hash and,
+// when duplicates are only flagged, the content the upload repeats.
+func (s *Service) fingerprint(ctx context.Context, author, hash string) ([]string, *entity.Duplicate, error) {
+	if hash == "" {
+		return nil, nil, nil
+	}
+
+	value, ok := imaging.ParseHash(hash)
+	if !ok {
+		return nil, nil, ErrInvalidInput
+	}
+
+	bands := imaging.Bands(value)
+
+	original, err := s.closestImage(ctx, author, value, bands)
+	if err != nil || original == nil {
+		return bands, nil, err
+	}
+
+	if s.duplicates.Mode == config.DuplicatesFlag {
+		return bands, original, nil
+	}
+
+	return nil, nil, &DuplicateError{Original: *original}
+}
+
+// duplicateScope narrows the content an upload is compared with.
+type duplicateScope struct {
+	author   string
+	statuses []string
+}
+
+// closestImage finds the content nearest to hash within the configured
+// distance, nil when there is none. Only approved content and what author
+// still has in moderation count: rejected content does not block uploads
+// and the slugs of what others have in moderation are not given away.
+func (s *Service) closestImage(ctx context.Context, author string, hash uint64, bands []string) (*entity.Duplicate, error) {
+	limit := min(s.duplicates.Distance, imaging.MaxHashDistance)
+
+	var closest *entity.Duplicate
+
+	consider := func(kind string, other entity.Duplicate, otherHash string) {
+		value, ok := imaging.ParseHash(otherHash)
+		if !ok {
+			return
+		}
+
+		distance := imaging.Distance(hash, value)
+		if distance > limit || (closest != nil && distance >= closest.Distance) {
+			return
+		}
+
+		other.Kind = kind
+		other.Distance = distance
+		closest = &other
+	}
+
+	scopes := []duplicateScope{{statuses: []string{entity.StatusApproved}}}
+	if author != "" {
+		scopes = append(scopes, duplicateScope{
+			author:   author,
+			statuses: []string{entity.StatusPending, entity.StatusAppealed},
+		})
+	}
+
+	for _, scope := range scopes {
+		mems, err := s.repo.GetSimilarMems(ctx, query.MemQuery{
+			HashBands: bands,
+			Author:    scope.author,
+			Statuses:  scope.statuses,
+		}, SimilarCandidates)
+		if err != nil {
+			return nil, ErrRepositoryFailed
+		}
+
+		for _, mem := range mems {
+			consider(entity.KindMem, entity.Duplicate{ID: mem.ID, Slug: mem.Slug}, mem.Hash)
+		}
+
+		wallpapers, err := s.repo.GetSimilarWallpapers(ctx, query.WallpaperQuery{
+			HashBands: bands,
+			Author:    scope.author,
+			Statuses:  scope.statuses,
+		}, SimilarCandidates)
+		if err != nil {
+			return nil, ErrRepositoryFailed
+		}
+
+		for _, wallpaper := range wallpapers {
+			consider(entity.KindWallpaper, entity.Duplicate{ID: wallpaper.ID, Slug: wallpaper.Slug}, wallpaper.Hash)
+		}
+	}
+
+	return closest, nil
+}
+
+// SimilarWallpapers lists approved wallpapers that look like the one of
+// ref, closest first.
+func (s *Service) SimilarWallpapers(viewer *entity.User, ref string, maxRating uint8, limit int) ([]entity.SimilarWallpaper, error) {
+	if ref == "" {
+		return nil, ErrInvalidInput
+	}
+
+	if limit <= 0 {
+		limit = DefaultSimilarLimit
+	}
+	limit = min(limit, MaxSimilarLimit)
+
+	ctx, cancel := s.context()
+	defer cancel()
+
+	wallpaper, err := s.getWallpaper(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = visible(viewer, wallpaper.Status, wallpaper.Author); err != nil {
+		return nil, err
+	}
+
+	similar := []entity.SimilarWallpaper{}
+
+	// wallpapers uploaded before hashing have nothing to compare.
+	hash, ok := imaging.ParseHash(wallpaper.Hash)
+	if !ok {
+		return similar, nil
+	}
+
+	candidates, err := s.repo.GetSimilarWallpapers(ctx, query.WallpaperQuery{
+		HashBands: imaging.Bands(hash),
+		Statuses:  []string{entity.StatusApproved},
+		MaxRating: &maxRating,
+	}, SimilarCandidates)
+	if err != nil {
+		return nil, ErrRepositoryFailed
+	}
+
+	for _, candidate := range candidates {
+		value, ok := imaging.ParseHash(candidate.Hash)
+		if !ok || candidate.ID == wallpaper.ID {
+			continue
+		}
+
+		similar = append(similar, entity.SimilarWallpaper{
+			Wallpaper: candidate,
+			Distance:  imaging.Distance(hash, value),
+		})
+	}
+
+	slices.SortStableFunc(similar, func(a, b entity.SimilarWallpaper) int {
+		return a.Distance - b.Distance
+	})
+
+	if len(similar) > limit {
+		similar = similar[:limit]
+	}
+
+	return similar, nil
+}
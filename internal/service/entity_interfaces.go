@@ -2,8 +2,14 @@ package service
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/pagination"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/token"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type (
@@ -12,6 +18,11 @@ type (
 		NewRepository
 		WallpaperRepository
 		MemRepository
+		UserRepository
+		SearchRepository
+		RequestRepository
+		OutboxRepository
+		DeadLetterRepository
 	}
 
 	Casher interface {
@@ -19,69 +30,132 @@ type (
 		NewCasher
 		MemCasher
 		WallpaperCasher
+		UserCasher
+		SessionCasher
+		SearchCasher
 	}
 
 	Sender interface {
-		SendToCensor(string, interface{}) error
+		SendToCensor(string, string, interface{}) error
+	}
+
+	FileStorage interface {
+		OpenFile(string, string) (io.ReadCloser, error)
+		ObjectExists(string, string) (bool, error)
+		ListFiles(string) ([]string, error)
+		PutBytes([]byte, string, string, string) error
+		RemoveFile(string, string) error
+		RemovePrefix(string, string) error
+	}
+
+	Tokens interface {
+		Issue(string) (*token.Pair, error)
+		Parse(string, string) (*token.Claims, error)
+	}
+
+	SessionCasher interface {
+		RevokeToken(context.Context, string, time.Duration) (bool, error)
+		IsTokenRevoked(context.Context, string) (bool, error)
+	}
+
+	SearchCasher interface {
+		AddSearchToCash(context.Context, query.SearchQuery, *entity.SearchResult) error
+		GetSearchFromCash(context.Context, query.SearchQuery) (*entity.SearchResult, error)
 	}
 
 	ArticleCasher interface {
 		AddArticleToCash(context.Context, *entity.Article) error
-		UpdateArticleInCash(context.Context, string, string, string, interface{}) error
-		GetArticleFromCash(context.Context, string, string) (*entity.Article, error)
-		DeleteArticleFromCash(context.Context, string, string) error
+		GetArticleFromCash(context.Context, string) (*entity.Article, error)
+		DeleteArticleFromCash(context.Context, *entity.Article) error
 	}
 
 	MemCasher interface {
 		AddMemToCash(context.Context, *entity.Mem) error
-		UpdateMemInCash(context.Context, string, string, string, interface{}) error
-		GetMemFromCash(context.Context, string, string) (*entity.Mem, error)
-		DeleteMemInCash(context.Context, string, string) error
+		GetMemFromCash(context.Context, string) (*entity.Mem, error)
+		DeleteMemFromCash(context.Context, *entity.Mem) error
 	}
 
 	NewCasher interface {
 		AddNewToCash(context.Context, *entity.New) error
-		UpdateNewInCash(context.Context, string, string, string, interface{}) error
-		GetNewFromCash(context.Context, string, string) (*entity.New, error)
-		DeleteNewFromCash(context.Context, string, string) error
+		GetNewFromCash(context.Context, string) (*entity.New, error)
+		DeleteNewFromCash(context.Context, *entity.New) error
 	}
 
 	WallpaperCasher interface {
 		AddWallpaperToCash(context.Context, *entity.Wallpaper) error
-		UpdateWallpaperInCash(context.Context, string, string, string, interface{}) error
-		GetWallpaperFromCash(context.Context, string, string) (*entity.Wallpaper, error)
-		DeleteWallpaperFromCash(context.Context, string, string) error
+		GetWallpaperFromCash(context.Context, string) (*entity.Wallpaper, error)
+		DeleteWallpaperFromCash(context.Context, *entity.Wallpaper) error
+	}
+
+	UserCasher interface {
+		AddUserToCash(context.Context, *entity.User) error
+		GetUserFromCash(context.Context, string) (*entity.User, error)
+		DeleteUserFromCash(context.Context, string) error
 	}
 
 	ArticleRepository interface {
 		CreateArticle(context.Context, *entity.Article) error
-		UpdateArticle(context.Context, map[string]interface{}, map[string]interface{}) error
-		DeleteArticle(context.Context, map[string]interface{}) error
-		GetArticle(context.Context, map[string]interface{}) (*entity.Article, error)
-		GetArticlesLimited(context.Context, map[string]interface{}, int64) ([]entity.Article, error)
+		UpdateArticle(context.Context, query.ArticleQuery, query.Update) error
+		DeleteArticle(context.Context, query.ArticleQuery) error
+		GetArticle(context.Context, query.ArticleQuery) (*entity.Article, error)
+		GetArticlesPage(context.Context, query.ArticleQuery, pagination.Request) ([]entity.Article, error)
 	}
 
 	MemRepository interface {
 		CreateMem(context.Context, *entity.Mem) error
-		UpdateMem(context.Context, map[string]interface{}, map[string]interface{}) error
-		DeleteMem(context.Context, map[string]interface{}) error
-		GetMem(context.Context, map[string]interface{}) (*entity.Mem, error)
-		GetMemsLimited(context.Context, map[string]interface{}, int64) ([]entity.Mem, error)
+		UpdateMem(context.Context, query.MemQuery, query.Update) error
+		DeleteMem(context.Context, query.MemQuery) error
+		GetMem(context.Context, query.MemQuery) (*entity.Mem, error)
+		GetMemsPage(context.Context, query.MemQuery, pagination.Request) ([]entity.Mem, error)
+		GetSimilarMems(context.Context, query.MemQuery, int64) ([]entity.Mem, error)
 	}
 
 	NewRepository interface {
 		CreateNew(context.Context, *entity.New) error
-		UpdateNew(context.Context, map[string]interface{}, map[string]interface{}) error
-		DeleteNew(context.Context, map[string]interface{}) error
-		GetNew(context.Context, map[string]interface{}) (*entity.New, error)
-		GetNewsLimited(context.Context, map[string]interface{}, int64) ([]entity.New, error)
+		UpdateNew(context.Context, query.NewQuery, query.Update) error
+		DeleteNew(context.Context, query.NewQuery) error
+		GetNew(context.Context, query.NewQuery) (*entity.New, error)
+		GetNewsPage(context.Context, query.NewQuery, pagination.Request) ([]entity.New, error)
 	}
 
 	WallpaperRepository interface {
 		CreateWallpaper(context.Context, *entity.Wallpaper) error
-		UpdateWallpaper(context.Context, map[string]interface{}, map[string]interface{}) error
-		DeleteWallpaper(context.Context, map[string]interface{}) error
-		GetWallpaper(context.Context, map[string]interface{}) (*entity.Wallpaper, error)
-		GetWallpapersLimited(context.Context, map[string]interface{}, int64) ([]entity.Wallpaper, error)
+		UpdateWallpaper(context.Context, query.WallpaperQuery, query.Update) error
+		DeleteWallpaper(context.Context, query.WallpaperQuery) error
+		GetWallpaper(context.Context, query.WallpaperQuery) (*entity.Wallpaper, error)
+		GetWallpapersPage(context.Context, query.WallpaperQuery, pagination.Request) ([]entity.Wallpaper, error)
+		GetSimilarWallpapers(context.Context, query.WallpaperQuery, int64) ([]entity.Wallpaper, error)
+		GetColoredWallpapers(context.Context, query.WallpaperQuery, int64) ([]entity.Wallpaper, error)
+	}
+
+	UserRepository interface {
+		CreateUser(context.Context, *entity.User) error
+		UpdateUser(context.Context, query.UserQuery, query.Update) error
+		DeleteUser(context.Context, query.UserQuery) error
+		GetUser(context.Context, query.UserQuery) (*entity.User, error)
+	}
+
+	SearchRepository interface {
+		Search(context.Context, query.SearchQuery) (*entity.SearchResult, error)
+	}
+
+	RequestRepository interface {
+		CreateRequest(context.Context, *entity.Request) error
+		GetRequests(context.Context, query.RequestQuery) ([]entity.Request, error)
+	}
+
+	OutboxRepository interface {
+		WithTransaction(context.Context, func(context.Context) error) error
+		CreateOutbox(context.Context, *entity.OutboxMessage) error
+		ClaimOutbox(context.Context, time.Duration) (*entity.OutboxMessage, error)
+		MarkOutboxSent(context.Context, primitive.ObjectID) error
+		MarkOutboxFailed(context.Context, primitive.ObjectID, string, time.Duration) error
+	}
+
+	DeadLetterRepository interface {
+		CreateDeadLetter(context.Context, *entity.DeadLetter) error
+		GetDeadLetter(context.Context, query.DeadLetterQuery) (*entity.DeadLetter, error)
+		GetDeadLettersPage(context.Context, query.DeadLetterQuery, pagination.Request) ([]entity.DeadLetter, error)
+		DeleteDeadLetters(context.Context, query.DeadLetterQuery) (int64, error)
 	}
 )
@@ -0,0 +1,16 @@
+package service
+
+import (
+	"github.com/osamikoyo/dark-fantasy-land/pkg/slug"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// identify gives new content its object id, unless the caller already
+// reserved one, and derives the public slug from it.
+func identify(id *primitive.ObjectID, base, fallback string) string {
+	if id.IsZero() {
+		*id = primitive.NewObjectID()
+	}
+
+	return slug.Make(base, fallback, *id)
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"image"
+	"sync"
+	"time"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"github.com/osamikoyo/dark-fantasy-land/internal/repository"
+)
+
+const (
+	ImageJobsSubject   = "images.jobs"
+	ImageEventsSubject = "images.processed"
+
+	// ImageJobTimeout bounds one job, rendering a 4k image takes far longer
+	// than the usual service timeout.
+	ImageJobTimeout = 2 * time.Minute
+)
+
+// imageTask is a job handed to a worker with what to do once it is done.
+type imageTask struct {
+	job  entity.ImageJob
+	done func(error)
+}
+
+func processingOf(processing string) string {
+	if processing == "" {
+		return entity.ProcessingReady
+	}
+
+	return processing
+}
+
+// RunImageWorkers processes submitted jobs on n workers until ctx is done,
+// a job a worker already took is finished before it returns.
+func (s *Service) RunImageWorkers(ctx context.Context, n int) {
+	var wg sync.WaitGroup
+
+	for range max(1, n) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case task := <-s.images:
+					task.done(s.ProcessImage(task.job))
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// SubmitImageJob hands job to a free worker and calls done with its result.
+// It blocks while every worker is busy, so the pool never holds more jobs
+// than it works on and the rest waits in the stream.
+func (s *Service) SubmitImageJob(ctx context.Context, job entity.ImageJob, done func(error)) error {
+	select {
+	case s.images <- imageTask{job: job, done: done}:
+		return nil
+	case <-ctx.Done():
+		return ErrTimeout
+	}
+}
+
+// ProcessImage renders the renditions of an uploaded wallpaper, extracts
+// its palette and announces the result. An image that does not decode fails the job for
+// good, any other error is returned so the job is retried.
+func (s *Service) ProcessImage(job entity.ImageJob) error {
+	if job.Kind != entity.KindWallpaper || job.ID.IsZero() {
+		return ErrInvalidInput
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ImageJobTimeout)
+	defer cancel()
+
+	wallpaper, err := s.repo.GetWallpaper(ctx, query.WallpaperQuery{Ref: query.ByID(job.ID)})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNotFound
+		}
+
+		return ErrRepositoryFailed
+	}
+
+	// a job is delivered again when its ack got lost.
+	if processingOf(wallpaper.Processing) == entity.ProcessingReady {
+		return nil
+	}
+
+	src, err := s.files.OpenFile(wallpaper.ImageName, s.buckets.WallpaperFull)
+	if err != nil {
+		return ErrStorageFailed
+	}
+	defer src.Close()
+
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return s.finishImage(ctx, wallpaper, err)
+	}
+
+	if err = s.renderWallpaper(wallpaper, img); err != nil {
+		return err
+	}
+
+	wallpaper.Palette, wallpaper.ColorBins = paletteOf(img)
+
+	return s.finishImage(ctx, wallpaper, nil)
+}
+
+// FailImageJob marks the upload of job as failed once it is given up on.
+func (s *Service) FailImageJob(job entity.ImageJob, cause error) error {
+	if job.Kind != entity.KindWallpaper || job.ID.IsZero() || cause == nil {
+		return ErrInvalidInput
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+
+	wallpaper, err := s.repo.GetWallpaper(ctx, query.WallpaperQuery{Ref: query.ByID(job.ID)})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNotFound
+		}
+
+		return ErrRepositoryFailed
+	}
+
+	return s.finishImage(ctx, wallpaper, cause)
+}
+
+// finishImage stores the outcome of processing wallpaper, failed when cause
+// is set, and queues the event announcing it in the same transaction.
+func (s *Service) finishImage(ctx context.Context, wallpaper *entity.Wallpaper, cause error) error {
+	event := entity.ImageEvent{
+		Kind:       entity.KindWallpaper,
+		ID:         wallpaper.ID,
+		Status:     entity.ProcessingReady,
+		Renditions: wallpaper.Renditions,
+	}
+
+	update := query.Update{
+		Set: map[string]interface{}{
+			"processing": entity.ProcessingReady,
+			"resolution": wallpaper.Resolution,
+			"renditions": wallpaper.Renditions,
+			"palette":    wallpaper.Palette,
+			"color_bins": wallpaper.ColorBins,
+		},
+		Unset: []string{"processing_error"},
+	}
+
+	if cause != nil {
+		event.Status = entity.ProcessingFailed
+		event.Error = cause.Error()
+
+		update = query.Update{Set: map[string]interface{}{
+			"processing":       entity.ProcessingFailed,
+			"processing_error": cause.Error(),
+		}}
+	}
+
+	if err := s.withOutbox(ctx, func(ctx context.Context) error {
+		return s.repo.UpdateWallpaper(ctx, query.WallpaperQuery{Ref: query.ByID(wallpaper.ID)}, update)
+	}, outboxEntry{subject: ImageEventsSubject, value: event}); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNotFound
+		}
+
+		return ErrRepositoryFailed
+	}
+
+	if err := s.casher.DeleteWallpaperFromCash(ctx, wallpaper); err != nil {
+		return ErrCacheDelFailed
+	}
+
+	return nil
+}
+
+// WallpaperProcessing tells a client polling for it where processing of
+// the wallpaper's upload stands.
+func (s *Service) WallpaperProcessing(viewer *entity.User, ref string) (*entity.ImageEvent, error) {
+	if ref == "" {
+		return nil, ErrInvalidInput
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+
+	wallpaper, err := s.getWallpaper(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = visible(viewer, wallpaper.Status, wallpaper.Author); err != nil {
+		return nil, err
+	}
+
+	return &entity.ImageEvent{
+		Kind:       entity.KindWallpaper,
+		ID:         wallpaper.ID,
+		Status:     processingOf(wallpaper.Processing),
+		Renditions: wallpaper.Renditions,
+		Error:      wallpaper.ProcessingError,
+	}, nil
+}
@@ -1,22 +1,43 @@
 package service
 
 import (
+	"context"
 	"errors"
-	"sync"
 
 	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
 	"github.com/osamikoyo/dark-fantasy-land/internal/repository"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/pagination"
 )
 
 func (s *Service) CreateMem(mem *entity.Mem) error {
-	if mem == nil {
+	if mem == nil || !entity.ValidRating(mem.Rating) {
 		return ErrInvalidInput
 	}
 
 	ctx, cancel := s.context()
 	defer cancel()
 
-	if err := s.repo.CreateMem(ctx, mem); err != nil {
+	if err := s.authorizeAuthor(ctx, mem.Author, ActionCreateContent); err != nil {
+		return err
+	}
+
+	bands, duplicate, err := s.fingerprint(ctx, mem.Author, mem.Hash)
+	if err != nil {
+		return err
+	}
+
+	mem.HashBands = bands
+	mem.DuplicateOf = duplicate
+
+	mem.Slug = identify(&mem.ID, mem.Description, "mem")
+	mem.Status = entity.StatusPending
+	mem.Views = 0
+	mem.Revision = 0
+
+	if err := s.createWithOutbox(ctx, "mems", mem, func(ctx context.Context) error {
+		return s.repo.CreateMem(ctx, mem)
+	}); err != nil {
 		if errors.Is(err, repository.ErrAlreadyExists) {
 			return ErrAlreadyExists
 		}
@@ -24,10 +45,6 @@ func (s *Service) CreateMem(mem *entity.Mem) error {
 		return ErrRepositoryFailed
 	}
 
-	if err := s.sendToCensor(mem, "mems"); err != nil {
-		return err
-	}
-
 	if err := s.casher.AddMemToCash(ctx, mem); err != nil {
 		return ErrCacheSetFailed
 	}
@@ -35,104 +52,118 @@ func (s *Service) CreateMem(mem *entity.Mem) error {
 	return nil
 }
 
-func (s *Service) UpdateMem(image_name, author string, update map[string]interface{}) error {
-	if author == "" || image_name == "" || update == nil {
+func (s *Service) UpdateMem(actor *entity.User, ref string, patch map[string]interface{}) error {
+	if ref == "" {
 		return ErrInvalidInput
 	}
 
 	ctx, cancel := s.context()
 	defer cancel()
 
-	filter := make(map[string]interface{})
-	filter["image_name"] = image_name
-	filter["author"] = author
+	mem, err := s.getMem(ctx, ref)
+	if err != nil {
+		return err
+	}
 
-	if err := s.repo.UpdateMem(ctx, filter, update); err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return ErrNotFound
-		}
+	if err = authorize(actor, ActionEditAny, mem.Author); err != nil {
+		return err
+	}
 
-		return ErrRepositoryFailed
+	update, err := mergePatch(patch, memPatchFields)
+	if err != nil {
+		return err
 	}
 
-	for key, value := range update {
-		if err := s.casher.UpdateMemInCash(ctx, image_name, author, key, value); err != nil {
-			return ErrCacheSetFailed
-		}
+	if err = s.applyPatch(ctx, patchTarget{
+		subject: "mems",
+		status:  mem.Status,
+		write: func(ctx context.Context, statuses []string, u query.Update) error {
+			return s.repo.UpdateMem(ctx, query.MemQuery{Ref: query.ByID(mem.ID), Statuses: statuses}, u)
+		},
+		reload: func(ctx context.Context) (interface{}, error) {
+			return s.repo.GetMem(ctx, query.MemQuery{Ref: query.ByID(mem.ID)})
+		},
+	}, update, resubmits(patch, memPatchFields)); err != nil {
+		return err
+	}
+
+	if err = s.casher.DeleteMemFromCash(ctx, mem); err != nil {
+		return ErrCacheDelFailed
 	}
 
 	return nil
 }
 
-func (s *Service) GetOneMem(image_name, author string) (*entity.Mem, error) {
-	if author == "" || image_name == "" {
-		return nil, ErrInvalidInput
+func (s *Service) DeleteMem(actor *entity.User, ref string) error {
+	if ref == "" {
+		return ErrInvalidInput
 	}
 
 	ctx, cancel := s.context()
 	defer cancel()
 
-	filter := make(map[string]interface{})
-	filter["image_name"] = image_name
-	filter["author"] = author
-
-	var (
-		memChan = make(chan *entity.Mem, 1)
-		errChan = make(chan error, 2)
-		wg      sync.WaitGroup
-	)
-
-	wg.Add(1)
+	mem, err := s.getMem(ctx, ref)
+	if err != nil {
+		return err
+	}
 
-	go func() {
-		defer wg.Done()
+	if err = authorize(actor, ActionEditAny, mem.Author); err != nil {
+		return err
+	}
 
-		mem, err := s.repo.GetMem(ctx, filter)
-		if err != nil {
-			errChan <- err
+	if err = s.repo.DeleteMem(ctx, query.MemQuery{Ref: query.ByID(mem.ID)}); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNotFound
 		}
-		memChan <- mem
-	}()
-
-	go func() {
-		defer wg.Done()
 
-		mem, err := s.casher.GetMemFromCash(ctx, image_name, author)
-		if err != nil {
-			errChan <- err
-		}
+		return ErrRepositoryFailed
+	}
 
-		memChan <- mem
-	}()
+	if err = s.casher.DeleteMemFromCash(ctx, mem); err != nil {
+		return ErrCacheDelFailed
+	}
 
-	errCount := 0
+	for _, name := range []string{mem.ImageName, mem.Poster} {
+		if name == "" {
+			continue
+		}
 
-	select {
-	case mem := <-memChan:
-		return mem, nil
-	case err := <-errChan:
-		errCount++
-		if errCount < 1 {
-			if errors.Is(err, repository.ErrNotFound) {
-				return nil, ErrNotFound
-			}
-
-			return nil, err
+		if err = s.removeImage(s.buckets.Mems, name); err != nil {
+			return err
 		}
 	}
 
-	return nil, ErrInternal
+	return nil
 }
 
-func (s *Service) GetManyMems(filter map[string]interface{}) ([]entity.Mem, error) {
-	if filter == nil {
+func (s *Service) GetOneMem(viewer *entity.User, ref string) (*entity.Mem, error) {
+	if ref == "" {
 		return nil, ErrInvalidInput
 	}
 
 	ctx, cancel := s.context()
 	defer cancel()
 
-	mems, err := s.repo.GetMemsLimited(ctx, filter, Limit)
+	mem, err := s.getMem(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = visible(viewer, mem.Status, mem.Author); err != nil {
+		return nil, err
+	}
+
+	_ = s.repo.UpdateMem(ctx, query.MemQuery{Ref: query.ByID(mem.ID)}, query.Update{Inc: map[string]int64{"views": 1}})
+
+	return mem, nil
+}
+
+func (s *Service) getMem(ctx context.Context, ref string) (*entity.Mem, error) {
+	if mem, err := s.casher.GetMemFromCash(ctx, ref); err == nil {
+		return mem, nil
+	}
+
+	mem, err := s.repo.GetMem(ctx, query.MemQuery{Ref: query.ParseRef(ref)})
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return nil, ErrNotFound
@@ -141,32 +172,31 @@ func (s *Service) GetManyMems(filter map[string]interface{}) ([]entity.Mem, erro
 		return nil, ErrRepositoryFailed
 	}
 
-	return mems, nil
+	_ = s.casher.AddMemToCash(ctx, mem)
+
+	return mem, nil
 }
 
-func (s *Service) DeleteMem(image_name, author string) error {
-	if author == "" || image_name == "" {
-		return ErrInvalidInput
-	}
+func (s *Service) GetManyMems(q query.MemQuery, page pagination.Request) (*pagination.Page[entity.Mem], error) {
+	q.Statuses = []string{entity.StatusApproved}
+
+	return s.memsPage(q, page)
+}
 
+func (s *Service) memsPage(q query.MemQuery, page pagination.Request) (*pagination.Page[entity.Mem], error) {
 	ctx, cancel := s.context()
 	defer cancel()
 
-	filter := make(map[string]interface{})
-	filter["image_name"] = image_name
-	filter["author"] = author
-
-	if err := s.repo.DeleteArticle(ctx, filter); err != nil {
+	mems, err := s.repo.GetMemsPage(ctx, q, page)
+	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
-			return ErrNotFound
+			return nil, ErrNotFound
 		}
 
-		return ErrRepositoryFailed
-	}
-
-	if err := s.casher.DeleteMemInCash(ctx, image_name, author); err != nil {
-		return ErrCacheDelFailed
+		return nil, ErrRepositoryFailed
 	}
 
-	return nil
+	return pagination.Build(mems, page, func(mem entity.Mem) pagination.Cursor {
+		return pagination.Cursor{Timestamp: mem.Timestamp, Views: mem.Views, ID: mem.ID}
+	}), nil
 }
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"image"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/imaging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MaxCaptions      = 8
+	MaxCaptionLength = 200
+)
+
+var (
+	templateNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,47}$`)
+	// templates are named after their sniffed format on upload.
+	templatePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,47}\.(jpg|png|gif)$`)
+)
+
+// MemTemplates lists the templates mems can be generated from.
+func (s *Service) MemTemplates() ([]string, error) {
+	templates, err := s.files.ListFiles(s.buckets.MemTemplates)
+	if err != nil {
+		return nil, ErrStorageFailed
+	}
+
+	sort.Strings(templates)
+
+	return templates, nil
+}
+
+// AddMemTemplate stores an image that passed validation as a new template
+// and returns its id, only moderators curate the library.
+func (s *Service) AddMemTemplate(actor *entity.User, name string, info imaging.Info, data []byte) (string, error) {
+	if err := authorize(actor, ActionModerate, ""); err != nil {
+		return "", err
+	}
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !templateNamePattern.MatchString(name) || len(data) == 0 {
+		return "", ErrInvalidInput
+	}
+
+	template := name + info.Extension()
+
+	exists, err := s.files.ObjectExists(template, s.buckets.MemTemplates)
+	if err != nil {
+		return "", ErrStorageFailed
+	}
+
+	if exists {
+		return "", ErrAlreadyExists
+	}
+
+	if err = s.files.PutBytes(data, s.buckets.MemTemplates, template, info.ContentType); err != nil {
+		return "", ErrStorageFailed
+	}
+
+	return template, nil
+}
+
+// GenerateMem draws captions onto the template, stores the picture and
+// creates mem for it in one step.
+func (s *Service) GenerateMem(mem *entity.Mem, template string, captions []imaging.Caption) error {
+	if mem == nil || !templatePattern.MatchString(template) || len(captions) == 0 || len(captions) > MaxCaptions {
+		return ErrInvalidInput
+	}
+
+	for _, caption := range captions {
+		if !validCaption(caption) {
+			return ErrInvalidInput
+		}
+	}
+
+	exists, err := s.files.ObjectExists(template, s.buckets.MemTemplates)
+	if err != nil {
+		return ErrStorageFailed
+	}
+
+	if !exists {
+		return ErrNotFound
+	}
+
+	src, err := s.files.OpenFile(template, s.buckets.MemTemplates)
+	if err != nil {
+		return ErrStorageFailed
+	}
+	defer src.Close()
+
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return ErrStorageFailed
+	}
+
+	data, err := imaging.DrawCaptions(img, captions)
+	if err != nil {
+		return ErrInternal
+	}
+
+	if mem.ID.IsZero() {
+		mem.ID = primitive.NewObjectID()
+	}
+
+	mem.ImageName = mem.ID.Hex() + ".jpg"
+	// every mem of a template shares most of its picture, hashing them would
+	// flag each one as a duplicate of the first.
+	mem.Hash = ""
+
+	if err = s.files.PutBytes(data, s.buckets.Mems, mem.ImageName, "image/jpeg"); err != nil {
+		return ErrStorageFailed
+	}
+
+	if err = s.CreateMem(mem); err != nil {
+		_ = s.files.RemoveFile(mem.ImageName, s.buckets.Mems)
+
+		return err
+	}
+
+	return nil
+}
+
+func validCaption(caption imaging.Caption) bool {
+	text := strings.TrimSpace(caption.Text)
+	if text == "" || utf8.RuneCountInString(text) > MaxCaptionLength {
+		return false
+	}
+
+	inside := func(f float64) bool { return f >= 0 && f <= 1 }
+
+	return inside(caption.X) && inside(caption.Y) && caption.Width > 0 && caption.Width <= 1 &&
+		caption.Anchor >= imaging.AnchorCenter && caption.Anchor <= imaging.AnchorBottom
+}
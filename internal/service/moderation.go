@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"github.com/osamikoyo/dark-fantasy-land/internal/repository"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CensorModerator signs the verdicts that came from the external censor.
+const CensorModerator = "censor"
+
+// transitions is the moderation state machine. Approved content can still
+// be taken down, rejected content goes back to a moderator only through an
+// appeal of its author, unless its rejection discarded what it was made of.
+var transitions = map[string][]string{
+	entity.StatusPending:  {entity.StatusApproved, entity.StatusRejected},
+	entity.StatusApproved: {entity.StatusRejected},
+	entity.StatusRejected: {entity.StatusAppealed},
+	entity.StatusAppealed: {entity.StatusApproved, entity.StatusRejected},
+}
+
+// moderated is the part of any content kind the state machine works with.
+type moderated struct {
+	kind     string
+	id       primitive.ObjectID
+	author   string
+	status   string
+	revision int64
+
+	// update applies u only while the content is still in status from and
+	// at revision, so two verdicts racing each other can not both win and
+	// neither lands on an edit it did not see.
+	update func(ctx context.Context, from string, u query.Update) error
+	forget func(ctx context.Context) error
+
+	// discard, when set, drops what rejected content should not keep. The
+	// rejection marks the content discarded, which is final.
+	discard   func() error
+	discarded bool
+}
+
+func statusOf(status string) string {
+	if status == "" {
+		return entity.StatusApproved
+	}
+
+	return status
+}
+
+func (s *Service) loadModerated(ctx context.Context, kind, ref string) (*moderated, error) {
+	if ref == "" {
+		return nil, ErrInvalidInput
+	}
+
+	switch kind {
+	case entity.KindArticle:
+		article, err := s.getArticle(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		return &moderated{
+			kind:     kind,
+			id:       article.ID,
+			author:   article.Author,
+			status:   statusOf(article.Status),
+			revision: article.Revision,
+			update: func(ctx context.Context, from string, u query.Update) error {
+				return s.repo.UpdateArticle(ctx, query.ArticleQuery{Ref: query.ByID(article.ID), Statuses: []string{from}, Revision: &article.Revision}, u)
+			},
+			forget: func(ctx context.Context) error {
+				return s.casher.DeleteArticleFromCash(ctx, article)
+			},
+		}, nil
+	case entity.KindNews:
+		n, err := s.getNew(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		return &moderated{
+			kind:     kind,
+			id:       n.ID,
+			author:   n.Author,
+			status:   statusOf(n.Status),
+			revision: n.Revision,
+			update: func(ctx context.Context, from string, u query.Update) error {
+				return s.repo.UpdateNew(ctx, query.NewQuery{Ref: query.ByID(n.ID), Statuses: []string{from}, Revision: &n.Revision}, u)
+			},
+			forget: func(ctx context.Context) error {
+				return s.casher.DeleteNewFromCash(ctx, n)
+			},
+		}, nil
+	case entity.KindMem:
+		mem, err := s.getMem(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		return &moderated{
+			kind:     kind,
+			id:       mem.ID,
+			author:   mem.Author,
+			status:   statusOf(mem.Status),
+			revision: mem.Revision,
+			update: func(ctx context.Context, from string, u query.Update) error {
+				return s.repo.UpdateMem(ctx, query.MemQuery{Ref: query.ByID(mem.ID), Statuses: []string{from}, Revision: &mem.Revision}, u)
+			},
+			forget: func(ctx context.Context) error {
+				return s.casher.DeleteMemFromCash(ctx, mem)
+			},
+		}, nil
+	case entity.KindWallpaper:
+		wallpaper, err := s.getWallpaper(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		return &moderated{
+			kind:     kind,
+			id:       wallpaper.ID,
+			author:   wallpaper.Author,
+			status:   statusOf(wallpaper.Status),
+			revision: wallpaper.Revision,
+			update: func(ctx context.Context, from string, u query.Update) error {
+				return s.repo.UpdateWallpaper(ctx, query.WallpaperQuery{Ref: query.ByID(wallpaper.ID), Statuses: []string{from}, Revision: &wallpaper.Revision}, u)
+			},
+			forget: func(ctx context.Context) error {
+				return s.casher.DeleteWallpaperFromCash(ctx, wallpaper)
+			},
+			// rejected images are not kept around, the author has to
+			// upload the wallpaper again instead of appealing.
+			discard: func() error {
+				return s.removeWallpaperImages(wallpaper)
+			},
+			discarded: wallpaper.Discarded,
+		}, nil
+	default:
+		return nil, ErrInvalidInput
+	}
+}
+
+// transition moves item to status to and records req as the verdict that
+// did it.
+func (s *Service) transition(ctx context.Context, item *moderated, to string, req *entity.Request) error {
+	if item.discarded || !slices.Contains(transitions[item.status], to) {
+		return ErrInvalidTransition
+	}
+
+	update := query.Update{Set: map[string]interface{}{"status": to}}
+
+	discard := to == entity.StatusRejected && item.discard != nil
+	if discard {
+		update.Set["discarded"] = true
+	}
+
+	req.Kind = item.kind
+	req.ContentID = item.id
+	req.From = item.status
+	req.To = to
+	req.Censored = to == entity.StatusRejected
+
+	if req.CensoredAt.IsZero() {
+		req.CensoredAt = time.Now()
+	}
+
+	// the status only moves together with the record of who moved it.
+	if err := s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := item.update(ctx, item.status, update); err != nil {
+			return err
+		}
+
+		return s.repo.CreateRequest(ctx, req)
+	}); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidTransition
+		}
+
+		return ErrRepositoryFailed
+	}
+
+	if err := item.forget(ctx); err != nil {
+		return ErrCacheDelFailed
+	}
+
+	if discard {
+		return item.discard()
+	}
+
+	return nil
+}
+
+// Verdict approves or rejects content on behalf of a moderator.
+func (s *Service) Verdict(actor *entity.User, kind, ref, status, description string) error {
+	if err := authorize(actor, ActionModerate, ""); err != nil {
+		return err
+	}
+
+	if status != entity.StatusApproved && status != entity.StatusRejected {
+		return ErrInvalidInput
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+
+	item, err := s.loadModerated(ctx, kind, ref)
+	if err != nil {
+		return err
+	}
+
+	return s.transition(ctx, item, status, &entity.Request{
+		Moderator:   actor.Username,
+		Description: description,
+	})
+}
+
+// Appeal sends rejected content back to the moderators, only its author
+// may do that.
+func (s *Service) Appeal(actor *entity.User, kind, ref, description string) error {
+	if actor == nil {
+		return ErrUnauthorized
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+
+	item, err := s.loadModerated(ctx, kind, ref)
+	if err != nil {
+		return err
+	}
+
+	if item.author != actor.Username {
+		return ErrForbidden
+	}
+
+	return s.transition(ctx, item, entity.StatusAppealed, &entity.Request{
+		Moderator:   actor.Username,
+		Description: description,
+	})
+}
+
+// ApplyCensorVerdict feeds a verdict of the external censor into the state
+// machine. The censor only judges fresh content, once a moderator or an
+// appeal got involved its late verdicts are refused.
+func (s *Service) ApplyCensorVerdict(kind string, req *entity.Request) error {
+	if req == nil || req.Payload["id"] == "" {
+		return ErrInvalidInput
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+
+	item, err := s.loadModerated(ctx, kind, req.Payload["id"])
+	if err != nil {
+		return err
+	}
+
+	if item.status != entity.StatusPending || !currentRevision(item, req.Payload["revision"]) {
+		return ErrInvalidTransition
+	}
+
+	to := entity.StatusApproved
+	if req.Censored {
+		to = entity.StatusRejected
+	}
+
+	req.Moderator = CensorModerator
+
+	return s.transition(ctx, item, to, req)
+}
+
+// currentRevision tells whether a verdict given on revision judged what
+// item says now. A censor that does not echo the revision is only trusted
+// with content that was never edited.
+func currentRevision(item *moderated, revision string) bool {
+	if revision == "" {
+		return item.revision == 0
+	}
+
+	n, err := strconv.ParseInt(revision, 10, 64)
+
+	return err == nil && n == item.revision
+}
+
+// ModerationHistory returns every verdict given on the content, its author
+// may follow their own content too.
+func (s *Service) ModerationHistory(actor *entity.User, kind, ref string) ([]entity.Request, error) {
+	if actor == nil {
+		return nil, ErrUnauthorized
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+
+	item, err := s.loadModerated(ctx, kind, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if item.author != actor.Username && !can(actor, ActionModerate) {
+		return nil, ErrForbidden
+	}
+
+	requests, err := s.repo.GetRequests(ctx, query.RequestQuery{Kind: kind, ContentID: item.id})
+	if err != nil {
+		return nil, ErrRepositoryFailed
+	}
+
+	return requests, nil
+}
+
+// ModerationQueue pages through content of kind in status, pending when
+// status is empty.
+func (s *Service) ModerationQueue(actor *entity.User, kind, status string, page pagination.Request) (interface{}, error) {
+	if err := authorize(actor, ActionModerate, ""); err != nil {
+		return nil, err
+	}
+
+	if status == "" {
+		status = entity.StatusPending
+	}
+
+	if _, ok := transitions[status]; !ok {
+		return nil, ErrInvalidInput
+	}
+
+	statuses := []string{status}
+
+	switch kind {
+	case entity.KindArticle:
+		return s.articlesPage(query.ArticleQuery{Statuses: statuses}, page)
+	case entity.KindNews:
+		return s.newsPage(query.NewQuery{Statuses: statuses}, page)
+	case entity.KindMem:
+		return s.memsPage(query.MemQuery{Statuses: statuses}, page)
+	case entity.KindWallpaper:
+		return s.wallpapersPage(query.WallpaperQuery{Statuses: statuses}, page)
+	default:
+		return nil, ErrInvalidInput
+	}
+}
@@ -1,22 +1,35 @@
 package service
 
 import (
+	"context"
 	"errors"
-	"sync"
 
 	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
 	"github.com/osamikoyo/dark-fantasy-land/internal/repository"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/pagination"
 )
 
-func (s *Service) CreateNew(new *entity.New) error {
-	if new == nil {
+func (s *Service) CreateNew(n *entity.New) error {
+	if n == nil || !entity.ValidRating(n.Censor) {
 		return ErrInvalidInput
 	}
 
 	ctx, cancel := s.context()
 	defer cancel()
 
-	if err := s.repo.CreateNew(ctx, new); err != nil {
+	if err := s.authorizeAuthor(ctx, n.Author, ActionPublishNews); err != nil {
+		return err
+	}
+
+	n.Slug = identify(&n.ID, n.Title, "news")
+	n.Status = entity.StatusPending
+	n.Views = 0
+	n.Revision = 0
+
+	if err := s.createWithOutbox(ctx, "news", n, func(ctx context.Context) error {
+		return s.repo.CreateNew(ctx, n)
+	}); err != nil {
 		if errors.Is(err, repository.ErrAlreadyExists) {
 			return ErrAlreadyExists
 		}
@@ -24,59 +37,73 @@ func (s *Service) CreateNew(new *entity.New) error {
 		return ErrRepositoryFailed
 	}
 
-	if err := s.sendToCensor(new, "news"); err != nil {
-		return err
-	}
-
-	if err := s.casher.AddNewToCash(ctx, new); err != nil {
+	if err := s.casher.AddNewToCash(ctx, n); err != nil {
 		return ErrCacheSetFailed
 	}
 
 	return nil
 }
 
-func (s *Service) UpdateNew(author, title string, update map[string]interface{}) error {
-	if author == "" || title == "" || update == nil {
+func (s *Service) UpdateNew(actor *entity.User, ref string, patch map[string]interface{}) error {
+	if ref == "" {
 		return ErrInvalidInput
 	}
 
 	ctx, cancel := s.context()
 	defer cancel()
 
-	filter := make(map[string]interface{})
-	filter["author"] = author
-	filter["title"] = title
+	n, err := s.getNew(ctx, ref)
+	if err != nil {
+		return err
+	}
 
-	if err := s.repo.UpdateNew(ctx, filter, update); err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return ErrNotFound
-		}
+	if err = authorize(actor, ActionEditAny, n.Author); err != nil {
+		return err
+	}
 
-		return ErrRepositoryFailed
+	update, err := mergePatch(patch, newPatchFields)
+	if err != nil {
+		return err
 	}
 
-	for key, value := range update {
-		if err := s.casher.UpdateNewInCash(ctx, author, title, key, value); err != nil {
-			return ErrCacheSetFailed
-		}
+	if err = s.applyPatch(ctx, patchTarget{
+		subject: "news",
+		status:  n.Status,
+		write: func(ctx context.Context, statuses []string, u query.Update) error {
+			return s.repo.UpdateNew(ctx, query.NewQuery{Ref: query.ByID(n.ID), Statuses: statuses}, u)
+		},
+		reload: func(ctx context.Context) (interface{}, error) {
+			return s.repo.GetNew(ctx, query.NewQuery{Ref: query.ByID(n.ID)})
+		},
+	}, update, resubmits(patch, newPatchFields)); err != nil {
+		return err
+	}
+
+	if err = s.casher.DeleteNewFromCash(ctx, n); err != nil {
+		return ErrCacheDelFailed
 	}
 
 	return nil
 }
 
-func (s *Service) DeleteNew(author, title string) error {
-	if author == "" || title == "" {
+func (s *Service) DeleteNew(actor *entity.User, ref string) error {
+	if ref == "" {
 		return ErrInvalidInput
 	}
 
 	ctx, cancel := s.context()
 	defer cancel()
 
-	filter := make(map[string]interface{})
-	filter["author"] = author
-	filter["title"] = title
+	n, err := s.getNew(ctx, ref)
+	if err != nil {
+		return err
+	}
 
-	if err := s.repo.DeleteNew(ctx, filter); err != nil {
+	if err = authorize(actor, ActionEditAny, n.Author); err != nil {
+		return err
+	}
+
+	if err = s.repo.DeleteNew(ctx, query.NewQuery{Ref: query.ByID(n.ID)}); err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return ErrNotFound
 		}
@@ -84,86 +111,69 @@ func (s *Service) DeleteNew(author, title string) error {
 		return ErrRepositoryFailed
 	}
 
-	if err := s.casher.DeleteNewFromCash(ctx, author, title); err != nil {
+	if err = s.casher.DeleteNewFromCash(ctx, n); err != nil {
 		return ErrCacheDelFailed
 	}
 
 	return nil
 }
 
-func (s *Service) GetOneNew(author, title string) (*entity.New, error) {
-	if author == "" || title == "" {
+func (s *Service) GetOneNew(viewer *entity.User, ref string, maxRating uint8) (*entity.New, error) {
+	if ref == "" {
 		return nil, ErrInvalidInput
 	}
 
 	ctx, cancel := s.context()
 	defer cancel()
 
-	filter := make(map[string]interface{})
-	filter["author"] = author
-	filter["title"] = title
-
-	var (
-		wg      sync.WaitGroup
-		newChan chan *entity.New
-		errChan chan error
-	)
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-
-		new, err := s.repo.GetNew(ctx, filter)
-		if err != nil {
-			if errors.Is(err, repository.ErrNotFound) {
-				err = ErrNotFound
-			}
+	n, err := s.getNew(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
 
-			errChan <- err
+	if err = visible(viewer, n.Status, n.Author); err != nil {
+		return nil, err
+	}
 
-			return
-		}
+	if n.Censor > maxRating {
+		return nil, ErrAboveRating
+	}
 
-		newChan <- new
-	}()
+	_ = s.repo.UpdateNew(ctx, query.NewQuery{Ref: query.ByID(n.ID)}, query.Update{Inc: map[string]int64{"views": 1}})
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
+	return n, nil
+}
 
-		new, err := s.casher.GetNewFromCash(ctx, author, title)
-		if err != nil {
-			errChan <- ErrCacheGetFailed
+func (s *Service) getNew(ctx context.Context, ref string) (*entity.New, error) {
+	if n, err := s.casher.GetNewFromCash(ctx, ref); err == nil {
+		return n, nil
+	}
 
-			return
+	n, err := s.repo.GetNew(ctx, query.NewQuery{Ref: query.ParseRef(ref)})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
 		}
 
-		newChan <- new
-	}()
-
-	errCount := 0
-
-	for errCount != 2 {
-		select {
-		case new := <-newChan:
-			return new, nil
-		case <-errChan:
-			errCount++
-		}
+		return nil, ErrRepositoryFailed
 	}
 
-	return nil, ErrInternal
+	_ = s.casher.AddNewToCash(ctx, n)
+
+	return n, nil
 }
 
-func (s *Service) GetManyNew(filter map[string]interface{}) ([]entity.New, error) {
-	if filter == nil {
-		return nil, ErrInvalidInput
-	}
+func (s *Service) GetManyNew(q query.NewQuery, page pagination.Request) (*pagination.Page[entity.New], error) {
+	q.Statuses = []string{entity.StatusApproved}
+
+	return s.newsPage(q, page)
+}
 
+func (s *Service) newsPage(q query.NewQuery, page pagination.Request) (*pagination.Page[entity.New], error) {
 	ctx, cancel := s.context()
 	defer cancel()
 
-	news, err := s.repo.GetNewsLimited(ctx, filter, Limit)
+	news, err := s.repo.GetNewsPage(ctx, q, page)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return nil, ErrNotFound
@@ -172,5 +182,7 @@ func (s *Service) GetManyNew(filter map[string]interface{}) ([]entity.New, error
 		return nil, ErrRepositoryFailed
 	}
 
-	return news, nil
+	return pagination.Build(news, page, func(n entity.New) pagination.Cursor {
+		return pagination.Cursor{Timestamp: n.Timestamp, Views: n.Views, ID: n.ID}
+	}), nil
 }
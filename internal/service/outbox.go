@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/repository"
+)
+
+const (
+	OutboxLease      = 30 * time.Second
+	OutboxRetryDelay = 10 * time.Second
+)
+
+// outboxEntry is one message queued by withOutbox.
+type outboxEntry struct {
+	subject string
+	value   interface{}
+}
+
+// createWithOutbox runs create and queues value for subject in the same
+// transaction, so content is never stored without being sent to the censor.
+func (s *Service) createWithOutbox(ctx context.Context, subject string, value interface{}, create func(context.Context) error) error {
+	return s.withOutbox(ctx, create, outboxEntry{subject: subject, value: value})
+}
+
+// withOutbox runs write and queues entries in the same transaction. The
+// entries are encoded once write is done, so a value may be filled by it.
+func (s *Service) withOutbox(ctx context.Context, write func(context.Context) error, entries ...outboxEntry) error {
+	return s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := write(ctx); err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			payload, err := sonic.Marshal(entry.value)
+			if err != nil {
+				return ErrInternal
+			}
+
+			msg := &entity.OutboxMessage{
+				Subject:   entry.subject,
+				Payload:   payload,
+				CreatedAt: time.Now(),
+			}
+
+			if err = s.repo.CreateOutbox(ctx, msg); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// RelayOutbox publishes every due message and reports how many went out.
+// It stops at the first failure, the broker is likely down then and the
+// rest waits for the next call. A message is published again when marking
+// it sent fails, so consumers of the censor subjects must tolerate
+// duplicates; our own verdict handling refuses a second verdict as late.
+func (s *Service) RelayOutbox(ctx context.Context) (int, error) {
+	sent := 0
+
+	for ctx.Err() == nil {
+		msg, err := s.claimOutbox(ctx)
+		if err != nil || msg == nil {
+			return sent, err
+		}
+
+		if err = s.relay(ctx, msg); err != nil {
+			return sent, err
+		}
+
+		sent++
+	}
+
+	return sent, ctx.Err()
+}
+
+func (s *Service) claimOutbox(ctx context.Context) (*entity.OutboxMessage, error) {
+	opCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	msg, err := s.repo.ClaimOutbox(opCtx, OutboxLease)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil
+		}
+
+		return nil, ErrRepositoryFailed
+	}
+
+	return msg, nil
+}
+
+func (s *Service) relay(ctx context.Context, msg *entity.OutboxMessage) error {
+	opCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	// an entry whose lease ran out while it was being published is relayed
+	// again, the stream drops the second copy by its id.
+	if err := s.sender.SendToCensor(msg.Subject, msg.ID.Hex(), json.RawMessage(msg.Payload)); err != nil {
+		_ = s.repo.MarkOutboxFailed(opCtx, msg.ID, err.Error(), OutboxRetryDelay)
+
+		return fmt.Errorf("relay %s to %s: %w", msg.ID.Hex(), msg.Subject, err)
+	}
+
+	if err := s.repo.MarkOutboxSent(opCtx, msg.ID); err != nil {
+		return ErrRepositoryFailed
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"bytes"
+	"cmp"
+	"image"
+	"slices"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/imaging"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/pagination"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// ColorCandidates caps the documents one color lookup ranks.
+	ColorCandidates = 1000
+
+	// MinColorWeight leaves out palette colors covering too little of the
+	// image to be what it looks like.
+	MinColorWeight = 0.05
+
+	// MaxColorDistance is how far, in delta-E, a palette color may be from
+	// the one asked for.
+	MaxColorDistance = imaging.ColorBinSize
+)
+
+// nearWallpaper is a candidate of a color lookup with its distance.
+type nearWallpaper struct {
+	wallpaper entity.Wallpaper
+	distance  float64
+}
+
+// paletteOf extracts the dominant colors of img and the bins they are
+// looked up by.
+func paletteOf(img image.Image) ([]entity.PaletteColor, []string) {
+	swatches := imaging.Palette(img, imaging.PaletteSize)
+
+	palette := make([]entity.PaletteColor, 0, len(swatches))
+	var bins []string
+
+	for _, swatch := range swatches {
+		palette = append(palette, entity.PaletteColor{
+			Hex:    swatch.Hex(),
+			L:      swatch.Lab.L,
+			A:      swatch.Lab.A,
+			B:      swatch.Lab.B,
+			Weight: swatch.Weight,
+		})
+
+		if swatch.Weight < MinColorWeight {
+			continue
+		}
+
+		if bin := imaging.ColorBin(swatch.Lab); !slices.Contains(bins, bin) {
+			bins = append(bins, bin)
+		}
+	}
+
+	return palette, bins
+}
+
+// colorDistance is how close the palette comes to target, its closest
+// color that covers enough of the image counts.
+func colorDistance(palette []entity.PaletteColor, target imaging.Lab) (float64, bool) {
+	best, found := 0.0, false
+
+	for _, c := range palette {
+		if c.Weight < MinColorWeight {
+			continue
+		}
+
+		d := imaging.DeltaE(imaging.Lab{L: c.L, A: c.A, B: c.B}, target)
+		if !found || d < best {
+			best, found = d, true
+		}
+	}
+
+	return best, found
+}
+
+// wallpapersNear pages through wallpapers matching q ranked by how close
+// their palette comes to q.NearColor. Ranking happens here, so the page
+// only covers the first ColorCandidates matches.
+func (s *Service) wallpapersNear(q query.WallpaperQuery, page pagination.Request) (*pagination.Page[entity.Wallpaper], error) {
+	c, ok := imaging.ParseHex(q.NearColor)
+	if !ok || page.Sort != pagination.SortNearest {
+		return nil, ErrInvalidInput
+	}
+
+	target := imaging.LabOf(c)
+	q.ColorBins = imaging.NearBins(target)
+
+	ctx, cancel := s.context()
+	defer cancel()
+
+	candidates, err := s.repo.GetColoredWallpapers(ctx, q, ColorCandidates)
+	if err != nil {
+		return nil, ErrRepositoryFailed
+	}
+
+	near := make([]nearWallpaper, 0, len(candidates))
+
+	for _, candidate := range candidates {
+		distance, ok := colorDistance(candidate.Palette, target)
+		if !ok || distance > MaxColorDistance {
+			continue
+		}
+
+		near = append(near, nearWallpaper{wallpaper: candidate, distance: distance})
+	}
+
+	// closest first, the id breaks ties so the cursor has a stable order.
+	order := func(distance float64, id primitive.ObjectID, n nearWallpaper) int {
+		if c := cmp.Compare(distance, n.distance); c != 0 {
+			return c
+		}
+
+		return bytes.Compare(id[:], n.wallpaper.ID[:])
+	}
+
+	slices.SortFunc(near, func(a, b nearWallpaper) int {
+		return order(a.distance, a.wallpaper.ID, b)
+	})
+
+	if after := page.After; after != nil {
+		near = slices.DeleteFunc(near, func(n nearWallpaper) bool {
+			return order(after.Distance, after.ID, n) >= 0
+		})
+	}
+
+	near = near[:min(int64(len(near)), page.Limit+1)]
+
+	wallpapers := make([]entity.Wallpaper, 0, len(near))
+	distances := make(map[primitive.ObjectID]float64, len(near))
+
+	for _, n := range near {
+		wallpapers = append(wallpapers, n.wallpaper)
+		distances[n.wallpaper.ID] = n.distance
+	}
+
+	return pagination.Build(wallpapers, page, func(wallpaper entity.Wallpaper) pagination.Cursor {
+		return pagination.Cursor{Distance: distances[wallpaper.ID], ID: wallpaper.ID}
+	}), nil
+}
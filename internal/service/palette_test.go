@@ -0,0 +1,103 @@
+package service
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/imaging"
+)
+
+func paletteColor(c color.NRGBA, weight float64) entity.PaletteColor {
+	lab := imaging.LabOf(c)
+
+	return entity.PaletteColor{L: lab.L, A: lab.A, B: lab.B, Weight: weight}
+}
+
+func TestColorDistance(t *testing.T) {
+	red := color.NRGBA{R: 0xc0, G: 0x20, B: 0x20, A: 0xff}
+	darkRed := color.NRGBA{R: 0xa0, G: 0x18, B: 0x18, A: 0xff}
+	blue := color.NRGBA{R: 0x20, G: 0x30, B: 0xc0, A: 0xff}
+
+	target := imaging.LabOf(red)
+
+	tests := []struct {
+		name     string
+		palette  []entity.PaletteColor
+		distance float64
+		found    bool
+	}{
+		{name: "empty"},
+		{name: "exact", palette: []entity.PaletteColor{paletteColor(red, 0.5)}, found: true},
+		{name: "closest counts", palette: []entity.PaletteColor{
+			paletteColor(blue, 0.7),
+			paletteColor(darkRed, 0.3),
+		}, distance: imaging.DeltaE(imaging.LabOf(darkRed), target), found: true},
+		{name: "at the weight limit", palette: []entity.PaletteColor{
+			paletteColor(blue, 0.95),
+			paletteColor(red, MinColorWeight),
+		}, found: true},
+		{name: "too small to count", palette: []entity.PaletteColor{
+			paletteColor(blue, 0.97),
+			paletteColor(red, MinColorWeight/2),
+		}, distance: imaging.DeltaE(imaging.LabOf(blue), target), found: true},
+		{name: "nothing counts", palette: []entity.PaletteColor{paletteColor(red, 0.01), paletteColor(blue, 0.02)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			distance, found := colorDistance(tt.palette, target)
+			if found != tt.found || math.Abs(distance-tt.distance) > 1e-9 {
+				t.Errorf("colorDistance = %v, %v, want %v, %v", distance, found, tt.distance, tt.found)
+			}
+		})
+	}
+}
+
+func TestPaletteOf(t *testing.T) {
+	red := color.NRGBA{R: 0xc0, G: 0x20, B: 0x20, A: 0xff}
+	teal := color.NRGBA{R: 0x10, G: 0x80, B: 0x80, A: 0xff}
+
+	// red covers all but a sliver of teal, too small to be looked up by.
+	img := image.NewNRGBA(image.Rect(0, 0, 200, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			c := red
+			if x < 4 {
+				c = teal
+			}
+
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	palette, bins := paletteOf(img)
+	if len(palette) == 0 {
+		t.Fatal("paletteOf gave no colors")
+	}
+
+	for _, c := range palette {
+		if len(c.Hex) != 7 || c.Hex[0] != '#' {
+			t.Errorf("palette color hex = %q, want #rrggbb", c.Hex)
+		}
+	}
+
+	if distance, ok := colorDistance(palette, imaging.LabOf(red)); !ok || distance > 3 {
+		t.Errorf("colorDistance to red = %v, %v, want close", distance, ok)
+	}
+
+	if want := imaging.ColorBin(imaging.LabOf(red)); !slices.Contains(bins, want) {
+		t.Errorf("bins = %v, want them to hold %s", bins, want)
+	}
+
+	if teal := imaging.ColorBin(imaging.LabOf(teal)); slices.Contains(bins, teal) {
+		t.Errorf("bins = %v, want them without the sliver of teal in %s", bins, teal)
+	}
+
+	if compacted := slices.Compact(slices.Sorted(slices.Values(bins))); len(compacted) != len(bins) {
+		t.Errorf("bins = %v, want no repeats", bins)
+	}
+}
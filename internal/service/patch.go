@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"github.com/osamikoyo/dark-fantasy-land/internal/repository"
+)
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindStrings
+	// kindRating takes a rating by name and stores its level.
+	kindRating
+	// kindWatermark takes one of the watermarks a wallpaper may carry.
+	kindWatermark
+)
+
+type patchField struct {
+	kind     fieldKind
+	required bool
+	// judged fields are what the censor and moderators look at, changing
+	// one sends the content back to them.
+	judged bool
+}
+
+// Fields a JSON merge patch may touch, everything else (author, timestamp,
+// image names and what was derived from the image) is fixed once the content
+// is created.
+var (
+	articlePatchFields = map[string]patchField{
+		"title":   {kind: kindString, required: true, judged: true},
+		"content": {kind: kindString, required: true, judged: true},
+		"topics":  {kind: kindStrings, judged: true},
+		"rating":  {kind: kindRating, required: true, judged: true},
+	}
+
+	memPatchFields = map[string]patchField{
+		"description": {kind: kindString, judged: true},
+		"topics":      {kind: kindStrings, judged: true},
+		"rating":      {kind: kindRating, required: true, judged: true},
+	}
+
+	newPatchFields = map[string]patchField{
+		"title":   {kind: kindString, required: true, judged: true},
+		"content": {kind: kindString, required: true, judged: true},
+		"topic":   {kind: kindString, judged: true},
+		"censor":  {kind: kindRating, required: true, judged: true},
+	}
+
+	wallpaperPatchFields = map[string]patchField{
+		"topic":     {kind: kindString, judged: true},
+		"rating":    {kind: kindRating, required: true, judged: true},
+		"watermark": {kind: kindWatermark},
+	}
+)
+
+// patchTarget is the content a patch is written to.
+type patchTarget struct {
+	// subject is where the censor is sent content of this kind.
+	subject string
+	status  string
+	// write applies u, only while the content is in one of statuses when
+	// any are given.
+	write func(ctx context.Context, statuses []string, u query.Update) error
+	// reload reads the content back once u is applied.
+	reload func(ctx context.Context) (interface{}, error)
+}
+
+// resubmits tells whether patch changes what the censor or a moderator
+// judges. Pending content is resubmitted too, the verdict it waits for was
+// given on what it said before.
+func resubmits(patch map[string]interface{}, fields map[string]patchField) bool {
+	for key := range patch {
+		if fields[key].judged {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyPatch writes update to target. When resubmit is set the content
+// goes back to pending under a new revision and, in the same transaction,
+// is queued for the censor again, so neither an approved post nor one
+// waiting for its verdict can be edited into anything. Verdicts on the
+// older revision are refused as stale, see ApplyCensorVerdict.
+func (s *Service) applyPatch(ctx context.Context, target patchTarget, update query.Update, resubmit bool) error {
+	if !resubmit {
+		if err := target.write(ctx, nil, update); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrNotFound
+			}
+
+			return ErrRepositoryFailed
+		}
+
+		return nil
+	}
+
+	update.Set["status"] = entity.StatusPending
+	update.Inc = map[string]int64{"revision": 1}
+
+	var content interface{}
+
+	if err := s.withOutbox(ctx, func(ctx context.Context) error {
+		// a verdict given meanwhile wins, the edit has to be sent again.
+		if err := target.write(ctx, []string{statusOf(target.status)}, update); err != nil {
+			return err
+		}
+
+		var err error
+		content, err = target.reload(ctx)
+
+		return err
+	}, outboxEntry{subject: target.subject, value: &content}); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidTransition
+		}
+
+		return ErrRepositoryFailed
+	}
+
+	return nil
+}
+
+// mergePatch turns an RFC 7396 merge patch over a flat document into a mongo
+// update, null members are removed and everything else is replaced.
+func mergePatch(patch map[string]interface{}, fields map[string]patchField) (query.Update, error) {
+	if len(patch) == 0 {
+		return query.Update{}, ErrInvalidInput
+	}
+
+	update := query.Update{Set: make(map[string]interface{})}
+
+	for key, value := range patch {
+		field, ok := fields[key]
+		if !ok {
+			return query.Update{}, ErrInvalidInput
+		}
+
+		if value == nil {
+			if field.required {
+				return query.Update{}, ErrInvalidInput
+			}
+
+			update.Unset = append(update.Unset, key)
+
+			continue
+		}
+
+		normalized, ok := normalizeField(field, value)
+		if !ok {
+			return query.Update{}, ErrInvalidInput
+		}
+
+		update.Set[key] = normalized
+	}
+
+	return update, nil
+}
+
+func normalizeField(field patchField, value interface{}) (interface{}, bool) {
+	switch field.kind {
+	case kindString:
+		str, ok := value.(string)
+		if !ok || (field.required && strings.TrimSpace(str) == "") {
+			return nil, false
+		}
+
+		return str, true
+	case kindStrings:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		out := make([]string, 0, len(items))
+		for _, item := range items {
+			str, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+
+			out = append(out, str)
+		}
+
+		return out, true
+	case kindRating:
+		name, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+
+		return entity.ParseRating(name)
+	case kindWatermark:
+		watermark, ok := value.(string)
+		if !ok || watermark == "" || !entity.ValidWatermark(watermark) {
+			return nil, false
+		}
+
+		return watermark, true
+	default:
+		return nil, false
+	}
+}
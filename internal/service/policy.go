@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+)
+
+type Action string
+
+const (
+	ActionCreateContent  Action = "content:create"
+	ActionEditOwn        Action = "content:edit_own"
+	ActionEditAny        Action = "content:edit_any"
+	ActionPublishNews    Action = "news:publish"
+	ActionManageRoles    Action = "users:manage_roles"
+	ActionModerate       Action = "content:moderate"
+	ActionReplayMessages Action = "messages:replay"
+)
+
+// SystemActor is used by background workers acting on behalf of the
+// platform itself, e.g. applying censor verdicts.
+var SystemActor = &entity.User{Username: "system", Role: entity.RoleAdmin}
+
+var rolePermissions = map[string][]Action{
+	entity.RoleReader: {},
+	entity.RoleAuthor: {
+		ActionCreateContent,
+		ActionEditOwn,
+	},
+	entity.RoleModerator: {
+		ActionCreateContent,
+		ActionEditOwn,
+		ActionEditAny,
+		ActionPublishNews,
+		ActionModerate,
+	},
+	entity.RoleAdmin: {
+		ActionCreateContent,
+		ActionEditOwn,
+		ActionEditAny,
+		ActionPublishNews,
+		ActionModerate,
+		ActionManageRoles,
+		ActionReplayMessages,
+	},
+}
+
+func validRole(role string) bool {
+	_, ok := rolePermissions[role]
+
+	return ok
+}
+
+// roleOf treats accounts created before roles existed as authors.
+func roleOf(user *entity.User) string {
+	if user.Role == "" {
+		return entity.RoleAuthor
+	}
+
+	return user.Role
+}
+
+func can(user *entity.User, action Action) bool {
+	if user == nil {
+		return false
+	}
+
+	for _, allowed := range rolePermissions[roleOf(user)] {
+		if allowed == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// authorize checks whether actor may perform action, owner is the author of
+// the content being changed and may be empty for actions without an owner.
+func authorize(actor *entity.User, action Action, owner string) error {
+	if actor == nil {
+		return ErrUnauthorized
+	}
+
+	if can(actor, action) {
+		return nil
+	}
+
+	if action == ActionEditAny && owner != "" && owner == actor.Username && can(actor, ActionEditOwn) {
+		return nil
+	}
+
+	return ErrForbidden
+}
+
+// authorizeAuthor resolves the stamped author of new content and checks
+// that their role allows action.
+func (s *Service) authorizeAuthor(ctx context.Context, author string, action Action) error {
+	if author == "" {
+		return ErrInvalidInput
+	}
+
+	user, err := s.getUser(ctx, author)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrUnknownAuthor
+		}
+
+		return err
+	}
+
+	return authorize(user, action, "")
+}
+
+// visible hides content that has not passed moderation from everyone but
+// its author and the moderators. Hidden content is reported as missing so
+// its existence does not leak.
+func visible(viewer *entity.User, status, author string) error {
+	if status == "" || status == entity.StatusApproved {
+		return nil
+	}
+
+	if viewer != nil && (viewer.Username == author || can(viewer, ActionModerate)) {
+		return nil
+	}
+
+	return ErrNotFound
+}
+
+// DefaultMaxRating is the limit of viewers who did not choose one.
+const DefaultMaxRating = entity.RatingAllAges
+
+// ViewerRating resolves the highest rating viewer may see: an explicitly
+// requested limit wins over the stored preference, anonymous viewers
+// without either get DefaultMaxRating.
+func ViewerRating(viewer *entity.User, requested string) (uint8, error) {
+	if requested != "" {
+		rating, ok := entity.ParseRating(requested)
+		if !ok {
+			return 0, ErrInvalidInput
+		}
+
+		return rating, nil
+	}
+
+	if viewer != nil {
+		if rating, ok := entity.ParseRating(viewer.MaxRating); ok {
+			return rating, nil
+		}
+	}
+
+	return DefaultMaxRating, nil
+}
@@ -0,0 +1,23 @@
+package service
+
+import "path"
+
+// PreviewKey is where the blurred preview of name in bucket is kept in the
+// previews bucket.
+func PreviewKey(bucket, name string) string {
+	return path.Join(bucket, name)
+}
+
+// removeImage drops name from bucket together with the blurred preview
+// rendered from it.
+func (s *Service) removeImage(bucket, name string) error {
+	if err := s.files.RemoveFile(name, bucket); err != nil {
+		return ErrStorageFailed
+	}
+
+	if err := s.files.RemoveFile(PreviewKey(bucket, name), s.buckets.Previews); err != nil {
+		return ErrStorageFailed
+	}
+
+	return nil
+}
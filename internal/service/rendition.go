@@ -0,0 +1,90 @@
+package service
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/imaging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// renditionKey is where one rendition of a document's image is stored, all
+// renditions of an upload share the document id as prefix.
+func renditionKey(id primitive.ObjectID, name string) string {
+	return id.Hex() + "/" + name + ".jpg"
+}
+
+// renderWallpaper stores every rendition src is large enough for and records
+// them, with the size of the original, on wallpaper.
+func (s *Service) renderWallpaper(wallpaper *entity.Wallpaper, src image.Image) error {
+	if wallpaper == nil || src == nil {
+		return ErrInvalidInput
+	}
+
+	bounds := src.Bounds()
+
+	renditions := make([]entity.Rendition, 0, len(imaging.WallpaperSpecs))
+
+	for i, spec := range imaging.WallpaperSpecs {
+		if i > 0 && !spec.Covers(bounds) {
+			continue
+		}
+
+		data, err := imaging.Render(src, spec)
+		if err != nil {
+			return ErrInternal
+		}
+
+		key := renditionKey(wallpaper.ID, spec.Name)
+
+		if err = s.files.PutBytes(data, s.buckets.WallpaperWatch, key, "image/jpeg"); err != nil {
+			return ErrStorageFailed
+		}
+
+		renditions = append(renditions, entity.Rendition{
+			Name:   spec.Name,
+			Key:    key,
+			Width:  spec.Width,
+			Height: spec.Height,
+			Bytes:  int64(len(data)),
+		})
+	}
+
+	wallpaper.Resolution = fmt.Sprintf("%dx%d", bounds.Dx(), bounds.Dy())
+	wallpaper.Renditions = renditions
+
+	return nil
+}
+
+// BestRendition picks the smallest rendition covering width x height, a zero
+// side is not constrained. When none is big enough the largest one wins.
+func BestRendition(renditions []entity.Rendition, width, height int) (entity.Rendition, bool) {
+	var best, largest *entity.Rendition
+
+	for i := range renditions {
+		r := &renditions[i]
+
+		if largest == nil || r.Width*r.Height > largest.Width*largest.Height {
+			largest = r
+		}
+
+		if r.Width < width || r.Height < height {
+			continue
+		}
+
+		if best == nil || r.Width*r.Height < best.Width*best.Height {
+			best = r
+		}
+	}
+
+	if best == nil {
+		best = largest
+	}
+
+	if best == nil {
+		return entity.Rendition{}, false
+	}
+
+	return *best, true
+}
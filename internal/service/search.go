@@ -0,0 +1,137 @@
+package service
+
+import (
+	"html"
+	"strings"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/pagination"
+)
+
+const (
+	SnippetLength  = 200
+	SnippetContext = 60
+)
+
+func (s *Service) Search(q query.SearchQuery) (*entity.SearchResult, error) {
+	q = q.Normalize()
+
+	if q.Text == "" {
+		return nil, ErrInvalidInput
+	}
+
+	for _, kind := range q.Types {
+		if !query.IsSearchType(kind) {
+			return nil, ErrInvalidInput
+		}
+	}
+
+	if q.Limit < 1 {
+		q.Limit = pagination.DefaultLimit
+	}
+	q.Limit = min(q.Limit, pagination.MaxLimit)
+
+	ctx, cancel := s.context()
+	defer cancel()
+
+	if result, err := s.casher.GetSearchFromCash(ctx, q); err == nil {
+		return result, nil
+	}
+
+	result, err := s.repo.Search(ctx, q)
+	if err != nil {
+		return nil, ErrRepositoryFailed
+	}
+
+	terms := searchTerms(q.Text)
+	for i := range result.Hits {
+		result.Hits[i].Snippet = snippet(result.Hits[i].Text, terms)
+		result.Hits[i].Text = ""
+	}
+
+	_ = s.casher.AddSearchToCash(ctx, q, result)
+
+	return result, nil
+}
+
+// searchTerms keeps the words of a mongo $search string, dropping the
+// phrase quotes and negated words that should not be highlighted.
+func searchTerms(text string) []string {
+	var terms []string
+
+	for _, word := range strings.Fields(strings.ReplaceAll(text, `"`, " ")) {
+		if strings.HasPrefix(word, "-") {
+			continue
+		}
+
+		terms = append(terms, strings.ToLower(word))
+	}
+
+	return terms
+}
+
+// snippet cuts a window of text around the first matched term and wraps
+// every term inside it in <mark>. The text is escaped, only the marks are
+// markup.
+func snippet(text string, terms []string) string {
+	runes := []rune(text)
+	// ToLower maps rune by rune, so positions in lower match runes.
+	lower := []rune(strings.ToLower(text))
+
+	start := 0
+	if at := firstTerm(lower, terms); at >= 0 {
+		start = max(0, at-SnippetContext)
+	}
+
+	end := min(len(runes), start+SnippetLength)
+
+	var b strings.Builder
+
+	if start > 0 {
+		b.WriteString("…")
+	}
+
+	for i := start; i < end; {
+		if length := termAt(lower, i, terms); length > 0 && i+length <= end {
+			b.WriteString("<mark>")
+			b.WriteString(html.EscapeString(string(runes[i : i+length])))
+			b.WriteString("</mark>")
+
+			i += length
+
+			continue
+		}
+
+		b.WriteString(html.EscapeString(string(runes[i])))
+		i++
+	}
+
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
+
+func firstTerm(text []rune, terms []string) int {
+	for i := range text {
+		if termAt(text, i, terms) > 0 {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func termAt(text []rune, at int, terms []string) int {
+	for _, term := range terms {
+		term := []rune(term)
+
+		if len(term) > 0 && at+len(term) <= len(text) && string(text[at:at+len(term)]) == string(term) {
+			return len(term)
+		}
+	}
+
+	return 0
+}
@@ -4,24 +4,32 @@ import (
 	"context"
 	"errors"
 	"time"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/config"
 )
 
 const (
-	Limit           = 20
 	RetrierAttemps  = 3
 	RetrierDuration = 2 * time.Second
 )
 
 var (
-	ErrNotFound         = errors.New("not found")
-	ErrTimeout          = errors.New("operation timeout")
-	ErrInvalidInput     = errors.New("invalid input")
-	ErrAlreadyExists    = errors.New("already exist")
-	ErrCacheSetFailed   = errors.New("cache set failed")
-	ErrCacheGetFailed   = errors.New("cache get failed")
-	ErrCacheDelFailed   = errors.New("cache delete failed")
-	ErrRepositoryFailed = errors.New("repository operation failed")
-	ErrInternal         = errors.New("internal service error")
+	ErrNotFound          = errors.New("not found")
+	ErrTimeout           = errors.New("operation timeout")
+	ErrInvalidInput      = errors.New("invalid input")
+	ErrAlreadyExists     = errors.New("already exist")
+	ErrCacheSetFailed    = errors.New("cache set failed")
+	ErrCacheGetFailed    = errors.New("cache get failed")
+	ErrCacheDelFailed    = errors.New("cache delete failed")
+	ErrRepositoryFailed  = errors.New("repository operation failed")
+	ErrInternal          = errors.New("internal service error")
+	ErrUnknownAuthor     = errors.New("author is not a registered user")
+	ErrUnauthorized      = errors.New("unauthorized")
+	ErrForbidden         = errors.New("forbidden")
+	ErrStorageFailed     = errors.New("file storage operation failed")
+	ErrInvalidTransition = errors.New("status transition not allowed")
+	ErrAboveRating       = errors.New("content is rated above the viewer's limit")
+	ErrDuplicate         = errors.New("image duplicates existing content")
 )
 
 type (
@@ -29,31 +37,48 @@ type (
 		repo   Repository
 		casher Casher
 		sender Sender
+		tokens Tokens
+		files  FileStorage
+
+		buckets    config.Buckets
+		duplicates config.Duplicates
+		watermark  config.Watermark
+
+		// images hands jobs to the workers of RunImageWorkers.
+		images chan imageTask
+		// renders holds a slot for every watermark being rendered.
+		renders chan struct{}
 
 		timeout time.Duration
 	}
 )
 
-func NewService(repo Repository, casher Casher, sender Sender, timeout time.Duration) *Service {
+func NewService(
+	repo Repository,
+	casher Casher,
+	sender Sender,
+	tokens Tokens,
+	files FileStorage,
+	buckets config.Buckets,
+	duplicates config.Duplicates,
+	watermark config.Watermark,
+	timeout time.Duration,
+) *Service {
 	return &Service{
-		repo:    repo,
-		casher:  casher,
-		timeout: timeout,
+		repo:       repo,
+		casher:     casher,
+		sender:     sender,
+		tokens:     tokens,
+		files:      files,
+		buckets:    buckets,
+		duplicates: duplicates,
+		watermark:  watermark,
+		images:     make(chan imageTask),
+		renders:    make(chan struct{}, max(1, watermark.Renders)),
+		timeout:    timeout,
 	}
 }
 
 func (s *Service) context() (context.Context, context.CancelFunc) {
 	return context.WithTimeout(context.Background(), s.timeout)
 }
-
-func (s *Service) sendToCensor(value interface{}, subj string) error {
-	if value == nil {
-		return ErrInvalidInput
-	}
-
-	if err := s.sender.SendToCensor(subj, value); err != nil {
-		return ErrInternal
-	}
-
-	return nil
-}
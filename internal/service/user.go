@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"github.com/osamikoyo/dark-fantasy-land/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	MinPasswordLength    = 8
+	MaxPasswordLength    = 72
+	MaxDisplayNameLength = 64
+	MaxBioLength         = 512
+	MaxRatingNameLength  = 16
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-z0-9_-]{3,32}$`)
+
+// profileFields are the user fields a profile update may touch.
+var profileFields = map[string]int{
+	"display_name": MaxDisplayNameLength,
+	"bio":          MaxBioLength,
+	"max_rating":   MaxRatingNameLength,
+}
+
+func (s *Service) RegisterUser(username, email, password string) (*entity.User, error) {
+	username = strings.ToLower(strings.TrimSpace(username))
+
+	if !usernamePattern.MatchString(username) {
+		return nil, ErrInvalidInput
+	}
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return nil, ErrInvalidInput
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	// only the bare address is kept, "Name <a@b>" parses too. It is lower
+	// cased so the unique index does not tell A@b from a@b.
+	user := &entity.User{
+		Username:     username,
+		Email:        strings.ToLower(addr.Address),
+		PasswordHash: string(hash),
+		Role:         entity.RoleAuthor,
+		DisplayName:  username,
+		Timestamp:    time.Now(),
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+
+	if err = s.repo.CreateUser(ctx, user); err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, ErrAlreadyExists
+		}
+
+		return nil, ErrRepositoryFailed
+	}
+
+	if err = s.casher.AddUserToCash(ctx, user); err != nil {
+		return nil, ErrCacheSetFailed
+	}
+
+	return user, nil
+}
+
+func (s *Service) GetUser(username string) (*entity.User, error) {
+	if username == "" {
+		return nil, ErrInvalidInput
+	}
+
+	ctx, cancel := s.context()
+	defer cancel()
+
+	return s.getUser(ctx, username)
+}
+
+// Profile is the public face of username as viewer, who may be anonymous,
+// sees it.
+func (s *Service) Profile(viewer *entity.User, username string) (*entity.Profile, error) {
+	user, err := s.GetUser(username)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &entity.Profile{
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Avatar:      user.Avatar,
+		Bio:         user.Bio,
+	}
+
+	if viewer != nil && (viewer.Username == user.Username || can(viewer, ActionManageRoles)) {
+		profile.Email = user.Email
+	}
+
+	return profile, nil
+}
+
+func (s *Service) getUser(ctx context.Context, username string) (*entity.User, error) {
+	user, err := s.casher.GetUserFromCash(ctx, username)
+	if err == nil && user.Username != "" {
+		return user, nil
+	}
+
+	user, err = s.repo.GetUser(ctx, query.UserQuery{Username: username})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+
+		return nil, ErrRepositoryFailed
+	}
+
+	_ = s.casher.AddUserToCash(ctx, user)
+
+	return user, nil
+}
+
+func (s *Service) UpdateProfile(username string, update map[string]interface{}) error {
+	if username == "" || len(update) == 0 {
+		return ErrInvalidInput
+	}
+
+	for key, value := range update {
+		limit, ok := profileFields[key]
+		if !ok {
+			return ErrInvalidInput
+		}
+
+		str, ok := value.(string)
+		if !ok || utf8.RuneCountInString(str) > limit {
+			return ErrInvalidInput
+		}
+	}
+
+	if name, ok := update["max_rating"].(string); ok {
+		if _, known := entity.ParseRating(name); !known {
+			return ErrInvalidInput
+		}
+	}
+
+	return s.updateUser(username, update)
+}
+
+func (s *Service) SetAvatar(username, avatar string) error {
+	if username == "" || avatar == "" {
+		return ErrInvalidInput
+	}
+
+	return s.updateUser(username, map[string]interface{}{"avatar": avatar})
+}
+
+func (s *Service) updateUser(username string, update map[string]interface{}) error {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	if err := s.repo.UpdateUser(ctx, query.UserQuery{Username: username}, query.Update{Set: update}); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNotFound
+		}
+
+		return ErrRepositoryFailed
+	}
+
+	if err := s.casher.DeleteUserFromCash(ctx, username); err != nil {
+		return ErrCacheDelFailed
+	}
+
+	return nil
+}
+
+func (s *Service) SetRole(actor *entity.User, username, role string) error {
+	if username == "" || !validRole(role) {
+		return ErrInvalidInput
+	}
+
+	if err := authorize(actor, ActionManageRoles, ""); err != nil {
+		return err
+	}
+
+	if actor.Username == username {
+		return ErrForbidden
+	}
+
+	return s.updateUser(username, map[string]interface{}{"role": role})
+}
+
+// BootstrapAdmins grants the admin role to already registered users, it lets
+// a fresh deployment get its first administrator without touching the db.
+func (s *Service) BootstrapAdmins(usernames []string) error {
+	for _, username := range usernames {
+		err := s.updateUser(username, map[string]interface{}{"role": entity.RoleAdmin})
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
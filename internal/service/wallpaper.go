@@ -1,32 +1,53 @@
 package service
 
 import (
+	"context"
 	"errors"
-	"sync"
 
 	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
 	"github.com/osamikoyo/dark-fantasy-land/internal/repository"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/pagination"
 )
 
 func (s *Service) CreateWallpaper(wallpaper *entity.Wallpaper) error {
-	if wallpaper == nil {
+	if wallpaper == nil || !entity.ValidRating(wallpaper.Rating) || !entity.ValidWatermark(wallpaper.Watermark) {
 		return ErrInvalidInput
 	}
 
 	ctx, cancel := s.context()
 	defer cancel()
 
-	if err := s.repo.CreateWallpaper(ctx, wallpaper); err != nil {
+	if err := s.authorizeAuthor(ctx, wallpaper.Author, ActionCreateContent); err != nil {
+		return err
+	}
+
+	bands, duplicate, err := s.fingerprint(ctx, wallpaper.Author, wallpaper.Hash)
+	if err != nil {
+		return err
+	}
+
+	wallpaper.HashBands = bands
+	wallpaper.DuplicateOf = duplicate
+
+	wallpaper.Slug = identify(&wallpaper.ID, wallpaper.Topic, "wallpaper")
+	wallpaper.Status = entity.StatusPending
+	wallpaper.Views = 0
+	wallpaper.Revision = 0
+	wallpaper.Processing = entity.ProcessingQueued
+
+	if err := s.withOutbox(ctx, func(ctx context.Context) error {
+		return s.repo.CreateWallpaper(ctx, wallpaper)
+	},
+		outboxEntry{subject: "wallpapers", value: wallpaper},
+		outboxEntry{subject: ImageJobsSubject, value: entity.ImageJob{Kind: entity.KindWallpaper, ID: wallpaper.ID}},
+	); err != nil {
 		if errors.Is(err, repository.ErrAlreadyExists) {
 			return ErrAlreadyExists
 		}
 		return ErrRepositoryFailed
 	}
 
-	if err := s.sendToCensor(wallpaper, "wallpapers"); err != nil {
-		return err
-	}
-
 	if err := s.casher.AddWallpaperToCash(ctx, wallpaper); err != nil {
 		return ErrCacheSetFailed
 	}
@@ -34,131 +55,178 @@ func (s *Service) CreateWallpaper(wallpaper *entity.Wallpaper) error {
 	return nil
 }
 
-func (s *Service) UpdateWallpaper(imageName, title string, update map[string]interface{}) error {
-	if imageName == "" || title == "" || update == nil {
+func (s *Service) UpdateWallpaper(actor *entity.User, ref string, patch map[string]interface{}) error {
+	if ref == "" {
 		return ErrInvalidInput
 	}
 
 	ctx, cancel := s.context()
 	defer cancel()
 
-	filter := map[string]interface{}{
-		"image_name": imageName,
-		"title":      title,
+	wallpaper, err := s.getWallpaper(ctx, ref)
+	if err != nil {
+		return err
 	}
 
-	if err := s.repo.UpdateWallpaper(ctx, filter, update); err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return ErrNotFound
-		}
-		return ErrRepositoryFailed
+	if err = authorize(actor, ActionEditAny, wallpaper.Author); err != nil {
+		return err
 	}
 
-	for key, value := range update {
-		if err := s.casher.UpdateWallpaperInCash(ctx, imageName, title, key, value); err != nil {
-			return ErrCacheSetFailed
-		}
+	update, err := mergePatch(patch, wallpaperPatchFields)
+	if err != nil {
+		return err
+	}
+
+	resubmit := resubmits(patch, wallpaperPatchFields)
+
+	// the images of a discarded wallpaper are gone, there is nothing left
+	// to judge again.
+	if resubmit && wallpaper.Discarded {
+		return ErrInvalidTransition
+	}
+
+	if err = s.applyPatch(ctx, patchTarget{
+		subject: "wallpapers",
+		status:  wallpaper.Status,
+		write: func(ctx context.Context, statuses []string, u query.Update) error {
+			return s.repo.UpdateWallpaper(ctx, query.WallpaperQuery{Ref: query.ByID(wallpaper.ID), Statuses: statuses}, u)
+		},
+		reload: func(ctx context.Context) (interface{}, error) {
+			return s.repo.GetWallpaper(ctx, query.WallpaperQuery{Ref: query.ByID(wallpaper.ID)})
+		},
+	}, update, resubmit); err != nil {
+		return err
+	}
+
+	if err = s.casher.DeleteWallpaperFromCash(ctx, wallpaper); err != nil {
+		return ErrCacheDelFailed
+	}
+
+	if _, ok := patch["watermark"]; ok {
+		return s.forgetWatermark(wallpaper)
 	}
 
 	return nil
 }
 
-func (s *Service) DeleteWallpaper(imageName, title string) error {
-	if imageName == "" || title == "" {
+func (s *Service) DeleteWallpaper(actor *entity.User, ref string) error {
+	if ref == "" {
 		return ErrInvalidInput
 	}
 
 	ctx, cancel := s.context()
 	defer cancel()
 
-	filter := map[string]interface{}{
-		"image_name": imageName,
-		"title":      title,
+	wallpaper, err := s.getWallpaper(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	if err = authorize(actor, ActionEditAny, wallpaper.Author); err != nil {
+		return err
 	}
 
-	if err := s.repo.DeleteWallpaper(ctx, filter); err != nil {
+	if err = s.repo.DeleteWallpaper(ctx, query.WallpaperQuery{Ref: query.ByID(wallpaper.ID)}); err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return ErrNotFound
 		}
+
 		return ErrRepositoryFailed
 	}
 
-	if err := s.casher.DeleteWallpaperFromCash(ctx, imageName, title); err != nil {
+	if err = s.casher.DeleteWallpaperFromCash(ctx, wallpaper); err != nil {
 		return ErrCacheDelFailed
 	}
 
-	return nil
+	return s.removeWallpaperImages(wallpaper)
+}
+
+func (s *Service) removeWallpaperImages(wallpaper *entity.Wallpaper) error {
+	if err := s.removeImage(s.buckets.WallpaperFull, wallpaper.ImageName); err != nil {
+		return err
+	}
+
+	// wallpapers uploaded before renditions keep a single watch copy under
+	// the image name.
+	watch := []string{wallpaper.ImageName}
+	for _, rendition := range wallpaper.Renditions {
+		watch = append(watch, rendition.Key)
+	}
+
+	for _, key := range watch {
+		if err := s.removeImage(s.buckets.WallpaperWatch, key); err != nil {
+			return err
+		}
+	}
+
+	return s.forgetWatermark(wallpaper)
 }
 
-func (s *Service) GetOneWallpaper(imageName, title string) (*entity.Wallpaper, error) {
-	if imageName == "" || title == "" {
+func (s *Service) GetOneWallpaper(viewer *entity.User, ref string) (*entity.Wallpaper, error) {
+	if ref == "" {
 		return nil, ErrInvalidInput
 	}
 
 	ctx, cancel := s.context()
 	defer cancel()
 
-	filter := map[string]interface{}{
-		"image_name": imageName,
-		"title":      title,
+	wallpaper, err := s.getWallpaper(ctx, ref)
+	if err != nil {
+		return nil, err
 	}
 
-	var (
-		wg            sync.WaitGroup
-		wallpaperChan = make(chan *entity.Wallpaper, 1)
-		errChan       = make(chan error, 2)
-	)
+	if err = visible(viewer, wallpaper.Status, wallpaper.Author); err != nil {
+		return nil, err
+	}
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		wallpaper, err := s.repo.GetWallpaper(ctx, filter)
-		if err != nil {
-			errChan <- err
-			return
-		}
-		wallpaperChan <- wallpaper
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		wallpaper, err := s.casher.GetWallpaperFromCash(ctx, imageName, title)
-		if err != nil {
-			errChan <- ErrCacheGetFailed
-			return
-		}
-		wallpaperChan <- wallpaper
-	}()
-
-	errCount := 0
-	for errCount != 2 {
-		select {
-		case wallpaper := <-wallpaperChan:
-			return wallpaper, nil
-		case <-errChan:
-			errCount++
+	_ = s.repo.UpdateWallpaper(ctx, query.WallpaperQuery{Ref: query.ByID(wallpaper.ID)}, query.Update{Inc: map[string]int64{"views": 1}})
+
+	return wallpaper, nil
+}
+
+func (s *Service) getWallpaper(ctx context.Context, ref string) (*entity.Wallpaper, error) {
+	if wallpaper, err := s.casher.GetWallpaperFromCash(ctx, ref); err == nil {
+		return wallpaper, nil
+	}
+
+	wallpaper, err := s.repo.GetWallpaper(ctx, query.WallpaperQuery{Ref: query.ParseRef(ref)})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
 		}
+
+		return nil, ErrRepositoryFailed
 	}
 
-	return nil, ErrInternal
+	_ = s.casher.AddWallpaperToCash(ctx, wallpaper)
+
+	return wallpaper, nil
 }
 
-func (s *Service) GetManyWallpapers(filter map[string]interface{}) ([]entity.Wallpaper, error) {
-	if filter == nil {
-		return nil, ErrInvalidInput
+func (s *Service) GetManyWallpapers(q query.WallpaperQuery, page pagination.Request) (*pagination.Page[entity.Wallpaper], error) {
+	q.Statuses = []string{entity.StatusApproved}
+
+	if q.NearColor != "" || page.Sort == pagination.SortNearest {
+		return s.wallpapersNear(q, page)
 	}
 
+	return s.wallpapersPage(q, page)
+}
+
+func (s *Service) wallpapersPage(q query.WallpaperQuery, page pagination.Request) (*pagination.Page[entity.Wallpaper], error) {
 	ctx, cancel := s.context()
 	defer cancel()
 
-	wallpapers, err := s.repo.GetWallpapersLimited(ctx, filter, Limit)
+	wallpapers, err := s.repo.GetWallpapersPage(ctx, q, page)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return nil, ErrNotFound
 		}
+
 		return nil, ErrRepositoryFailed
 	}
 
-	return wallpapers, nil
+	return pagination.Build(wallpapers, page, func(wallpaper entity.Wallpaper) pagination.Cursor {
+		return pagination.Cursor{Timestamp: wallpaper.Timestamp, Views: wallpaper.Views, ID: wallpaper.ID}
+	}), nil
 }
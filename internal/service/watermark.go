@@ -0,0 +1,216 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"github.com/osamikoyo/dark-fantasy-land/internal/repository"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/imaging"
+)
+
+const (
+	WatermarkQuality = 92
+
+	// WatermarkWait is how long a download waits for a free render slot.
+	WatermarkWait = 30 * time.Second
+)
+
+// watermarkKey is where the marked download of wallpaper is cached in the
+// previews bucket. Every anonymous viewer gets the same copy, a signed in
+// downloader of an invisibly marked wallpaper gets their own.
+func watermarkKey(wallpaper *entity.Wallpaper, downloader string) string {
+	if downloader == "" {
+		return path.Join("watermark", wallpaper.ID.Hex()+".jpg")
+	}
+
+	// usernames from before the username rules may hold a slash.
+	return watermarkPrefix(wallpaper) + url.PathEscape(downloader) + ".jpg"
+}
+
+func watermarkPrefix(wallpaper *entity.Wallpaper) string {
+	return path.Join("watermark", wallpaper.ID.Hex()) + "/"
+}
+
+func markedVisibly(wallpaper *entity.Wallpaper) bool {
+	return wallpaper.Watermark == entity.WatermarkVisible || wallpaper.Watermark == entity.WatermarkBoth
+}
+
+func markedInvisibly(wallpaper *entity.Wallpaper) bool {
+	return wallpaper.Watermark == entity.WatermarkInvisible || wallpaper.Watermark == entity.WatermarkBoth
+}
+
+// WatermarkedWallpaper opens the full image of wallpaper with its watermark
+// applied for viewer, who may be anonymous.
+func (s *Service) WatermarkedWallpaper(viewer *entity.User, wallpaper *entity.Wallpaper) (io.ReadCloser, error) {
+	if wallpaper == nil || wallpaper.Watermark == "" {
+		return nil, ErrInvalidInput
+	}
+
+	downloader := ""
+	if viewer != nil && markedInvisibly(wallpaper) {
+		downloader = viewer.Username
+	}
+
+	key := watermarkKey(wallpaper, downloader)
+
+	exists, err := s.files.ObjectExists(key, s.buckets.Previews)
+	if err != nil {
+		return nil, ErrStorageFailed
+	}
+
+	if exists {
+		obj, err := s.files.OpenFile(key, s.buckets.Previews)
+		if err != nil {
+			return nil, ErrStorageFailed
+		}
+
+		return obj, nil
+	}
+
+	// rendering decodes the full image, only a few may run at once.
+	wait := time.NewTimer(WatermarkWait)
+	defer wait.Stop()
+
+	select {
+	case s.renders <- struct{}{}:
+		defer func() { <-s.renders }()
+	case <-wait.C:
+		return nil, ErrTimeout
+	}
+
+	data, err := s.renderWatermark(wallpaper, downloader)
+	if err != nil {
+		return nil, err
+	}
+
+	// a failed upload only means the next download renders it again.
+	_ = s.files.PutBytes(data, s.buckets.Previews, key, "image/jpeg")
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *Service) renderWatermark(wallpaper *entity.Wallpaper, downloader string) ([]byte, error) {
+	src, err := s.files.OpenFile(wallpaper.ImageName, s.buckets.WallpaperFull)
+	if err != nil {
+		return nil, ErrStorageFailed
+	}
+	defer src.Close()
+
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return nil, ErrStorageFailed
+	}
+
+	var out *image.RGBA
+
+	if markedInvisibly(wallpaper) {
+		mark := imaging.Mark{Wallpaper: wallpaper.ID, Downloader: downloader}
+
+		marked, ok := imaging.EmbedMark(img, mark, s.watermark.Key)
+		if !ok {
+			// usernames from before the username rules may not fit the
+			// mark, the wallpaper alone is still worth carrying.
+			mark.Downloader = ""
+
+			if marked, ok = imaging.EmbedMark(img, mark, s.watermark.Key); !ok {
+				return nil, ErrInternal
+			}
+		}
+
+		out = marked
+	} else {
+		out = image.NewRGBA(img.Bounds())
+		draw.Draw(out, out.Rect, img, out.Rect.Min, draw.Src)
+	}
+
+	if markedVisibly(wallpaper) {
+		if err = imaging.DrawCorner(out, "© "+wallpaper.Author, s.watermark.Corner, s.watermark.Opacity); err != nil {
+			return nil, ErrInternal
+		}
+	}
+
+	var buf bytes.Buffer
+	if err = jpeg.Encode(&buf, out, &jpeg.Options{Quality: WatermarkQuality}); err != nil {
+		return nil, ErrInternal
+	}
+
+	return buf.Bytes(), nil
+}
+
+// forgetWatermark drops the cached downloads once the watermark settings
+// of wallpaper change or it is gone.
+func (s *Service) forgetWatermark(wallpaper *entity.Wallpaper) error {
+	if err := s.files.RemoveFile(watermarkKey(wallpaper, ""), s.buckets.Previews); err != nil {
+		return ErrStorageFailed
+	}
+
+	if err := s.files.RemovePrefix(watermarkPrefix(wallpaper), s.buckets.Previews); err != nil {
+		return ErrStorageFailed
+	}
+
+	return nil
+}
+
+// VerifyWatermark looks for the invisible mark in img. Which wallpaper it
+// is and who made it is only told about approved wallpapers, who
+// downloaded the marked copy only to the author and moderators.
+func (s *Service) VerifyWatermark(actor *entity.User, img image.Image) (*entity.WatermarkMatch, error) {
+	if actor == nil {
+		return nil, ErrUnauthorized
+	}
+
+	if img == nil {
+		return nil, ErrInvalidInput
+	}
+
+	mark, ok := imaging.ReadMark(img, s.watermark.Key)
+	if !ok {
+		return &entity.WatermarkMatch{}, nil
+	}
+
+	match := &entity.WatermarkMatch{Found: true, Wallpaper: mark.Wallpaper}
+
+	ctx, cancel := s.context()
+	defer cancel()
+
+	wallpaper, err := s.findMarkedWallpaper(ctx, mark)
+	if err != nil {
+		return nil, err
+	}
+
+	if wallpaper != nil && visible(actor, wallpaper.Status, wallpaper.Author) == nil {
+		match.Slug = wallpaper.Slug
+		match.Author = wallpaper.Author
+	}
+
+	if can(actor, ActionModerate) || (wallpaper != nil && wallpaper.Author == actor.Username) {
+		match.Downloader = mark.Downloader
+	}
+
+	return match, nil
+}
+
+// findMarkedWallpaper loads the wallpaper a mark points at, nil when it was
+// deleted since.
+func (s *Service) findMarkedWallpaper(ctx context.Context, mark imaging.Mark) (*entity.Wallpaper, error) {
+	wallpaper, err := s.repo.GetWallpaper(ctx, query.WallpaperQuery{Ref: query.ByID(mark.Wallpaper)})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil
+		}
+
+		return nil, ErrRepositoryFailed
+	}
+
+	return wallpaper, nil
+}
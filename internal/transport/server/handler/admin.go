@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type roleRequest struct {
+	Role string `json:"role"`
+}
+
+func (h *Handler) SetUserRole(c echo.Context) error {
+	var req roleRequest
+
+	if err := c.Bind(&req); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.service.SetRole(currentUser(c), c.Param("username"), req.Role); err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.String(http.StatusOK, "role updated")
+}
+
+func (h *Handler) GetDeadLetters(c echo.Context) error {
+	page, err := pageRequest(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	letters, err := h.service.DeadLetters(currentUser(c), c.QueryParam("subject"), page)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(http.StatusOK, letters)
+}
+
+func (h *Handler) GetDeadLetter(c echo.Context) error {
+	letter, err := h.service.DeadLetter(currentUser(c), c.Param("id"))
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(http.StatusOK, letter)
+}
+
+func (h *Handler) ReplayDeadLetter(c echo.Context) error {
+	if err := h.service.ReplayDeadLetter(currentUser(c), c.Param("id")); err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.String(http.StatusOK, "dead letter replayed")
+}
+
+func (h *Handler) PurgeDeadLetters(c echo.Context) error {
+	deleted, err := h.service.PurgeDeadLetters(currentUser(c), c.Param("id"), c.QueryParam("subject"))
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]int64{"deleted": deleted})
+}
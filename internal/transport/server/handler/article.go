@@ -6,6 +6,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func (h *Handler) CreateArticle(c echo.Context) error {
@@ -15,45 +16,67 @@ func (h *Handler) CreateArticle(c echo.Context) error {
 		return c.String(http.StatusBadRequest, err.Error())
 	}
 
+	article.ID = primitive.NilObjectID
+	article.Author = currentUser(c).Username
 	article.Timestamp = time.Now()
 
 	if err := h.service.CreateArticle(&article); err != nil {
-		return c.String(http.StatusInternalServerError, err.Error())
+		return c.String(errorStatus(err), err.Error())
 	}
 
-	return c.String(http.StatusCreated, "article created")
+	return c.JSON(http.StatusCreated, article)
 }
 
 func (h *Handler) GetArticle(c echo.Context) error {
-	author := c.Param("author")
-	title := c.Param("title")
+	maxRating, err := viewerRating(c)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
 
-	article, err := h.service.GetOneArticle(author, title)
+	article, err := h.service.GetOneArticle(currentUser(c), c.Param("slug"), maxRating)
 	if err != nil {
-		return c.String(http.StatusInternalServerError, err.Error())
+		return c.String(errorStatus(err), err.Error())
 	}
 
 	return c.JSON(http.StatusOK, article)
 }
 
 func (h *Handler) GetArticles(c echo.Context) error {
-	params := []string{"author", "title", "timestamp", "content"}
-
-	filter := make(map[string]interface{})
-	for _, p := range params {
-		filter[p] = c.Param(p)
+	q, err := articleQuery(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
 	}
 
-	for key, value := range filter {
-		if value == "" {
-			delete(filter, key)
-		}
+	page, err := pageRequest(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
 	}
 
-	articles, err := h.service.GetMoreArticles(filter)
+	articles, err := h.service.GetMoreArticles(q, page)
 	if err != nil {
-		return c.String(http.StatusInternalServerError, err.Error())
+		return c.String(errorStatus(err), err.Error())
 	}
 
 	return c.JSON(http.StatusOK, articles)
 }
+
+func (h *Handler) UpdateArticle(c echo.Context) error {
+	patch, err := readMergePatch(c)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	if err = h.service.UpdateArticle(currentUser(c), c.Param("slug"), patch); err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.String(http.StatusOK, "article updated")
+}
+
+func (h *Handler) DeleteArticle(c echo.Context) error {
+	if err := h.service.DeleteArticle(currentUser(c), c.Param("slug")); err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
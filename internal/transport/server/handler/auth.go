@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (h *Handler) Login(c echo.Context) error {
+	var req loginRequest
+
+	if err := c.Bind(&req); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	pair, err := h.service.Login(req.Username, req.Password)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(http.StatusOK, pair)
+}
+
+func (h *Handler) Refresh(c echo.Context) error {
+	var req refreshRequest
+
+	if err := c.Bind(&req); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	pair, err := h.service.Refresh(req.RefreshToken)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(http.StatusOK, pair)
+}
+
+func (h *Handler) Logout(c echo.Context) error {
+	var req refreshRequest
+
+	if err := c.Bind(&req); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.service.Logout(bearerToken(c), req.RefreshToken); err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/service"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/imaging"
+)
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput), errors.Is(err, service.ErrUnknownAuthor),
+		errors.Is(err, imaging.ErrInvalidImage):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrForbidden), errors.Is(err, service.ErrAboveRating):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrAlreadyExists), errors.Is(err, service.ErrInvalidTransition),
+		errors.Is(err, service.ErrDuplicate):
+		return http.StatusConflict
+	case errors.Is(err, imaging.ErrTooLarge), errors.Is(err, imaging.ErrTooManyPixels),
+		errors.Is(err, imaging.ErrTooManyFrames), errors.Is(err, imaging.ErrTooLong):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, errUnsupportedPatch), errors.Is(err, imaging.ErrUnsupportedFormat):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, service.ErrTimeout):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
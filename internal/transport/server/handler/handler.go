@@ -14,36 +14,87 @@ type Handler struct {
 	cfg *config.Config
 }
 
-func NewHandler(service *service.Service) *Handler {
+func NewHandler(service *service.Service, storage *storage.Storage, cfg *config.Config) *Handler {
 	return &Handler{
 		service: service,
+		storage: storage,
+		cfg:     cfg,
 	}
 }
 
 func (h *Handler) RegisterRouters(e *echo.Echo) {
 	articles := e.Group("/article")
 
-	articles.POST("/create", h.CreateArticle)
-	articles.GET("/get/one", h.GetArticle)
-	articles.GET("/get/more", h.GetArticles)
+	articles.POST("/create", h.CreateArticle, h.Authenticate)
+	articles.GET("/get/more", h.GetArticles, h.Identify)
+	articles.GET("/:slug", h.GetArticle, h.Identify)
+	articles.PATCH("/:slug", h.UpdateArticle, h.Authenticate)
+	articles.DELETE("/:slug", h.DeleteArticle, h.Authenticate)
 
 	mems := e.Group("/mem")
 
-	mems.POST("/create", h.CreateMem)
-	mems.GET("/get/info", h.GetMemInfo)
-	mems.GET("/get/image", h.GetMemImage)
-	mems.GET("/get/more", h.GetMems)
+	mems.POST("/create", h.CreateMem, h.LimitUpload, h.Authenticate)
+	mems.POST("/generate", h.GenerateMem, h.Authenticate)
+	mems.GET("/templates", h.GetMemTemplates)
+	mems.GET("/templates/:template", h.GetMemTemplate)
+	mems.POST("/templates", h.UploadMemTemplate, h.LimitUpload, h.Authenticate)
+	mems.GET("/get/more", h.GetMems, h.Identify)
+	mems.GET("/:slug", h.GetMemInfo, h.Identify)
+	mems.GET("/:slug/image", h.GetMemImage, h.Identify)
+	mems.GET("/:slug/poster", h.GetMemPoster, h.Identify)
+	mems.PATCH("/:slug", h.UpdateMem, h.Authenticate)
+	mems.DELETE("/:slug", h.DeleteMem, h.Authenticate)
 
 	wallpapers := e.Group("/wallpaper")
 
-	wallpapers.POST("/create", h.CreateWallpaper)
-	wallpapers.GET("/get/info", h.GetWallpaperInfo)
-	wallpapers.GET("/get/image", h.GetWallpaperImage)
-	wallpapers.GET("/get/more", h.GetWallpapers)
+	wallpapers.POST("/create", h.CreateWallpaper, h.LimitUpload, h.Authenticate)
+	wallpapers.GET("/get/more", h.GetWallpapers, h.Identify)
+	wallpapers.POST("/watermark/verify", h.VerifyWatermark, h.LimitUpload, h.Authenticate)
+	wallpapers.GET("/:slug", h.GetWallpaperInfo, h.Identify)
+	wallpapers.GET("/:slug/image", h.GetWallpaperImage, h.Identify)
+	wallpapers.GET("/:slug/processing", h.GetWallpaperProcessing, h.Identify)
+	wallpapers.GET("/:slug/similar", h.GetSimilarWallpapers, h.Identify)
+	wallpapers.GET("/:slug/download", h.DownloadWallpaper, h.Identify)
+	wallpapers.PATCH("/:slug", h.UpdateWallpaper, h.Authenticate)
+	wallpapers.DELETE("/:slug", h.DeleteWallpaper, h.Authenticate)
 
 	news := e.Group("/news")
 
-	news.POST("/create", h.CreateArticle)
-	news.GET("/get/one", h.GetNew)
-	news.GET("/get/more", h.GetNews)
+	news.POST("/create", h.CreateNew, h.Authenticate)
+	news.GET("/get/more", h.GetNews, h.Identify)
+	news.GET("/:slug", h.GetNew, h.Identify)
+	news.PATCH("/:slug", h.UpdateNew, h.Authenticate)
+	news.DELETE("/:slug", h.DeleteNew, h.Authenticate)
+
+	e.GET("/search", h.Search, h.Identify)
+
+	users := e.Group("/user")
+
+	users.POST("/register", h.RegisterUser)
+	users.GET("/:username", h.GetUser, h.Identify)
+	users.PATCH("/:username/profile", h.UpdateProfile, h.Authenticate)
+	users.GET("/:username/avatar", h.GetAvatar)
+	users.POST("/:username/avatar", h.UploadAvatar, h.LimitUpload, h.Authenticate)
+
+	auth := e.Group("/auth")
+
+	auth.POST("/login", h.Login)
+	auth.POST("/refresh", h.Refresh)
+	auth.POST("/logout", h.Logout, h.Authenticate)
+
+	moderation := e.Group("/moderation", h.Authenticate)
+
+	moderation.GET("/:kind", h.ModerationQueue)
+	moderation.GET("/:kind/:slug/history", h.ModerationHistory)
+	moderation.POST("/:kind/:slug/verdict", h.Verdict)
+	moderation.POST("/:kind/:slug/appeal", h.Appeal)
+
+	admin := e.Group("/admin", h.Authenticate)
+
+	admin.PUT("/users/:username/role", h.SetUserRole)
+	admin.GET("/dead-letters", h.GetDeadLetters)
+	admin.DELETE("/dead-letters", h.PurgeDeadLetters)
+	admin.GET("/dead-letters/:id", h.GetDeadLetter)
+	admin.POST("/dead-letters/:id/replay", h.ReplayDeadLetter)
+	admin.DELETE("/dead-letters/:id", h.PurgeDeadLetters)
 }
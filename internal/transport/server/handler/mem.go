@@ -1,67 +1,171 @@
 package handler
 
 import (
+	"bytes"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/imaging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func (h *Handler) CreateMem(c echo.Context) error {
-	var mem entity.Mem
+	rating, err := ratingValue(c.FormValue("rating"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
 
-	if err := c.Bind(mem); err != nil {
+	topics, err := formList(c, "topics")
+	if err != nil {
 		return c.String(http.StatusBadRequest, err.Error())
 	}
 
-	if err := h.service.CreateMem(&mem); err != nil {
-		return c.String(http.StatusInternalServerError, err.Error())
+	// the entity has no form tags, multipart fields are read one by one.
+	mem := entity.Mem{
+		Topics:      topics,
+		Rating:      rating,
+		Description: c.FormValue("description"),
+	}
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		return c.String(formStatus(err), err.Error())
 	}
 
-	return c.String(http.StatusCreated, "mem created")
+	upload, err := h.readImage(file)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	if mem.Hash, err = hashImage(upload.Data); err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	mem.ID = primitive.NewObjectID()
+	mem.ImageName = objectName(mem.ID, upload.Info)
+	mem.Author = currentUser(c).Username
+	mem.Timestamp = time.Now()
+	mem.Poster, mem.Animation = "", nil
+	mem.Credits = h.credits(upload.Meta)
+
+	if upload.Info.Format == "gif" {
+		err = h.uploadAnimation(upload.Data, &mem)
+	} else {
+		err = h.storage.PutBytes(upload.Data, h.cfg.MinioBuckets.Mems, mem.ImageName, upload.Info.ContentType)
+	}
+	if err != nil {
+		return h.createFailed(c, err, h.cfg.MinioBuckets.Mems, mem.ImageName, mem.Poster)
+	}
+
+	if err = h.service.CreateMem(&mem); err != nil {
+		return h.createFailed(c, err, h.cfg.MinioBuckets.Mems, mem.ImageName, mem.Poster)
+	}
+
+	return c.JSON(http.StatusCreated, mem)
 }
 
-func (h *Handler) GetMemInfo(c echo.Context) error {
-	image_name := c.Param("image_name")
-	author := c.Param("author")
+// uploadAnimation stores a GIF mem, scaled down when needed, and for an
+// animated one its poster frame.
+func (h *Handler) uploadAnimation(data []byte, mem *entity.Mem) error {
+	animation, err := imaging.ProcessGIF(bytes.NewReader(data), imaging.AnimationLimits{
+		MaxFrames:   h.cfg.MaxGifFrames,
+		MaxPixels:   h.cfg.MaxGifPixels,
+		MaxDuration: h.cfg.MaxGifDuration,
+		MaxSide:     h.cfg.MaxGifSide,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = h.storage.PutBytes(animation.Data, h.cfg.MinioBuckets.Mems, mem.ImageName, "image/gif"); err != nil {
+		return err
+	}
+
+	if animation.Frames < 2 {
+		return nil
+	}
 
-	mem, err := h.service.GetOneMem(image_name, author)
+	mem.Poster = mem.ID.Hex() + "-poster.jpg"
+	mem.Animation = &entity.Animation{
+		Frames:    animation.Frames,
+		Duration:  animation.Duration.Milliseconds(),
+		LoopCount: animation.LoopCount,
+	}
+
+	return h.storage.PutBytes(animation.Poster, h.cfg.MinioBuckets.Mems, mem.Poster, "image/jpeg")
+}
+
+func (h *Handler) GetMemInfo(c echo.Context) error {
+	mem, err := h.service.GetOneMem(currentUser(c), c.Param("slug"))
 	if err != nil {
-		return c.String(http.StatusInternalServerError, err.Error())
+		return c.String(errorStatus(err), err.Error())
 	}
 
 	return c.JSON(http.StatusOK, mem)
 }
 
 func (h *Handler) GetMemImage(c echo.Context) error {
-	image_name := c.Param("image_name")
+	mem, err := h.service.GetOneMem(currentUser(c), c.Param("slug"))
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
 
-	obj, err := h.storage.DownloadFile(image_name, "mems")
+	return h.serveImage(c, h.cfg.MinioBuckets.Mems, mem.ImageName, mem.Rating)
+}
+
+// GetMemPoster serves the still picture lists show, the image itself for
+// mems that are not animated.
+func (h *Handler) GetMemPoster(c echo.Context) error {
+	mem, err := h.service.GetOneMem(currentUser(c), c.Param("slug"))
 	if err != nil {
-		c.String(http.StatusInternalServerError, err.Error())
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	if mem.Poster == "" {
+		return h.serveImage(c, h.cfg.MinioBuckets.Mems, mem.ImageName, mem.Rating)
 	}
 
-	return c.Stream(http.StatusOK, "application/octet-stream", obj)
+	return h.serveImage(c, h.cfg.MinioBuckets.Mems, mem.Poster, mem.Rating)
 }
 
 func (h *Handler) GetMems(c echo.Context) error {
-	params := []string{"author", "title", "image_name", "timestamp"}
+	q, err := memQuery(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
 
-	filter := make(map[string]interface{})
-	for _, p := range params {
-		filter[p] = c.Param(p)
+	page, err := pageRequest(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
 	}
 
-	for key, value := range filter {
-		if value == "" {
-			delete(filter, key)
-		}
+	mems, err := h.service.GetManyMems(q, page)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
 	}
 
-	wallpapers, err := h.service.GetManyWallpapers(filter)
+	return c.JSON(http.StatusOK, mems)
+}
+
+func (h *Handler) UpdateMem(c echo.Context) error {
+	patch, err := readMergePatch(c)
 	if err != nil {
-		return c.String(http.StatusInternalServerError, err.Error())
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	if err = h.service.UpdateMem(currentUser(c), c.Param("slug"), patch); err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.String(http.StatusOK, "mem updated")
+}
+
+func (h *Handler) DeleteMem(c echo.Context) error {
+	if err := h.service.DeleteMem(currentUser(c), c.Param("slug")); err != nil {
+		return c.String(errorStatus(err), err.Error())
 	}
 
-	return c.JSON(http.StatusOK, wallpapers)
+	return c.NoContent(http.StatusNoContent)
 }
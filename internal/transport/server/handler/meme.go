@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/imaging"
+)
+
+// captionRequest places a caption, X, Y and Width are fractions of the
+// template size and Anchor is one of "center", "top" or "bottom". Left out
+// they give a centred caption spanning most of the width.
+type captionRequest struct {
+	Text   string   `json:"text"`
+	X      *float64 `json:"x"`
+	Y      *float64 `json:"y"`
+	Width  *float64 `json:"width"`
+	Anchor string   `json:"anchor"`
+}
+
+type generateRequest struct {
+	Template    string           `json:"template"`
+	Top         string           `json:"top"`
+	Bottom      string           `json:"bottom"`
+	Captions    []captionRequest `json:"captions"`
+	Description string           `json:"description"`
+	Topics      []string         `json:"topics"`
+	Rating      string           `json:"rating"`
+}
+
+var anchors = map[string]int{
+	"":       imaging.AnchorCenter,
+	"center": imaging.AnchorCenter,
+	"top":    imaging.AnchorTop,
+	"bottom": imaging.AnchorBottom,
+}
+
+func (req generateRequest) captions() ([]imaging.Caption, bool) {
+	var captions []imaging.Caption
+
+	if req.Top != "" {
+		captions = append(captions, imaging.TopCaption(req.Top))
+	}
+
+	if req.Bottom != "" {
+		captions = append(captions, imaging.BottomCaption(req.Bottom))
+	}
+
+	for _, c := range req.Captions {
+		anchor, ok := anchors[c.Anchor]
+		if !ok {
+			return nil, false
+		}
+
+		caption := imaging.Caption{Text: c.Text, X: 0.5, Y: 0.5, Width: 1 - 2*imaging.CaptionMargin, Anchor: anchor}
+
+		if c.X != nil {
+			caption.X = *c.X
+		}
+		if c.Y != nil {
+			caption.Y = *c.Y
+		}
+		if c.Width != nil {
+			caption.Width = *c.Width
+		}
+
+		captions = append(captions, caption)
+	}
+
+	return captions, true
+}
+
+func (h *Handler) GenerateMem(c echo.Context) error {
+	var req generateRequest
+
+	if err := c.Bind(&req); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	captions, ok := req.captions()
+	if !ok {
+		return c.String(http.StatusBadRequest, "unknown caption anchor")
+	}
+
+	rating, err := ratingValue(req.Rating)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	mem := entity.Mem{
+		Topics:      req.Topics,
+		Author:      currentUser(c).Username,
+		Timestamp:   time.Now(),
+		Rating:      rating,
+		Description: req.Description,
+	}
+
+	if err = h.service.GenerateMem(&mem, req.Template, captions); err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, mem)
+}
+
+func (h *Handler) GetMemTemplates(c echo.Context) error {
+	templates, err := h.service.MemTemplates()
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(http.StatusOK, templates)
+}
+
+func (h *Handler) GetMemTemplate(c echo.Context) error {
+	obj, err := h.storage.DownloadFile(c.Param("template"), h.cfg.MinioBuckets.MemTemplates)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.Stream(http.StatusOK, "application/octet-stream", obj)
+}
+
+func (h *Handler) UploadMemTemplate(c echo.Context) error {
+	file, err := c.FormFile("image")
+	if err != nil {
+		return c.String(formStatus(err), err.Error())
+	}
+
+	upload, err := h.readImage(file)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	template, err := h.service.AddMemTemplate(currentUser(c), c.FormValue("name"), upload.Info, upload.Data)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, map[string]string{"template": template})
+}
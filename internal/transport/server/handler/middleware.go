@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+)
+
+const userContextKey = "user"
+
+// MultipartOverhead is what an upload request may carry on top of the
+// image: boundaries, part headers and the other form fields.
+const MultipartOverhead = 1 << 20
+
+// LimitUpload caps the body of upload routes before any of it is parsed,
+// an image over MaxUploadBytes is refused without being read whole.
+func (h *Handler) LimitUpload(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		limit := h.cfg.MaxUploadBytes + MultipartOverhead
+
+		req := c.Request()
+		if req.ContentLength > limit {
+			return c.String(http.StatusRequestEntityTooLarge, "request body too large")
+		}
+
+		req.Body = http.MaxBytesReader(c.Response(), req.Body, limit)
+
+		return next(c)
+	}
+}
+
+// Authenticate resolves the bearer token into the calling user and rejects
+// the request when there is none.
+func (h *Handler) Authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		raw := bearerToken(c)
+		if raw == "" {
+			return c.String(http.StatusUnauthorized, "missing bearer token")
+		}
+
+		user, err := h.service.Authenticate(raw)
+		if err != nil {
+			return c.String(errorStatus(err), err.Error())
+		}
+
+		c.Set(userContextKey, user)
+
+		return next(c)
+	}
+}
+
+// Identify resolves the bearer token like Authenticate but lets anonymous
+// callers through, for routes that only show more to signed in users. A bad
+// token is still rejected rather than silently downgraded.
+func (h *Handler) Identify(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		raw := bearerToken(c)
+		if raw == "" {
+			return next(c)
+		}
+
+		user, err := h.service.Authenticate(raw)
+		if err != nil {
+			return c.String(errorStatus(err), err.Error())
+		}
+
+		c.Set(userContextKey, user)
+
+		return next(c)
+	}
+}
+
+func bearerToken(c echo.Context) string {
+	header := c.Request().Header.Get(echo.HeaderAuthorization)
+
+	scheme, raw, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(raw)
+}
+
+func currentUser(c echo.Context) *entity.User {
+	user, _ := c.Get(userContextKey).(*entity.User)
+
+	return user
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type verdictRequest struct {
+	Status      string `json:"status"`
+	Description string `json:"description"`
+}
+
+type appealRequest struct {
+	Description string `json:"description"`
+}
+
+func (h *Handler) ModerationQueue(c echo.Context) error {
+	page, err := pageRequest(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	queue, err := h.service.ModerationQueue(currentUser(c), c.Param("kind"), c.QueryParam("status"), page)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(http.StatusOK, queue)
+}
+
+func (h *Handler) ModerationHistory(c echo.Context) error {
+	requests, err := h.service.ModerationHistory(currentUser(c), c.Param("kind"), c.Param("slug"))
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(http.StatusOK, requests)
+}
+
+func (h *Handler) Verdict(c echo.Context) error {
+	var req verdictRequest
+
+	if err := c.Bind(&req); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.service.Verdict(currentUser(c), c.Param("kind"), c.Param("slug"), req.Status, req.Description); err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.String(http.StatusOK, "verdict recorded")
+}
+
+func (h *Handler) Appeal(c echo.Context) error {
+	var req appealRequest
+
+	if err := c.Bind(&req); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.service.Appeal(currentUser(c), c.Param("kind"), c.Param("slug"), req.Description); err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.String(http.StatusOK, "appeal filed")
+}
@@ -2,9 +2,11 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func (h *Handler) CreateNew(c echo.Context) error {
@@ -14,43 +16,67 @@ func (h *Handler) CreateNew(c echo.Context) error {
 		return c.String(http.StatusBadRequest, err.Error())
 	}
 
+	new.ID = primitive.NilObjectID
+	new.Author = currentUser(c).Username
+	new.Timestamp = time.Now()
+
 	if err := h.service.CreateNew(&new); err != nil {
-		return c.String(http.StatusInternalServerError, err.Error())
+		return c.String(errorStatus(err), err.Error())
 	}
 
-	return c.String(http.StatusCreated, "new created")
+	return c.JSON(http.StatusCreated, new)
 }
 
 func (h *Handler) GetNew(c echo.Context) error {
-	author := c.Param("author")
-	title := c.Param("title")
+	maxRating, err := viewerRating(c)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
 
-	new, err := h.service.GetOneNew(author, title)
+	new, err := h.service.GetOneNew(currentUser(c), c.Param("slug"), maxRating)
 	if err != nil {
-		return c.String(http.StatusInternalServerError, err.Error())
+		return c.String(errorStatus(err), err.Error())
 	}
 
 	return c.JSON(http.StatusOK, new)
 }
 
 func (h *Handler) GetNews(c echo.Context) error {
-	params := []string{"author", "title", "timestamp", "content"}
-
-	filter := make(map[string]interface{})
-	for _, p := range params {
-		filter[p] = c.Param(p)
+	q, err := newQuery(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
 	}
 
-	for key, value := range filter {
-		if value == "" {
-			delete(filter, key)
-		}
+	page, err := pageRequest(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
 	}
 
-	news, err := h.service.GetManyNew(filter)
+	news, err := h.service.GetManyNew(q, page)
 	if err != nil {
-		return c.String(http.StatusInternalServerError, err.Error())
+		return c.String(errorStatus(err), err.Error())
 	}
 
 	return c.JSON(http.StatusOK, news)
 }
+
+func (h *Handler) UpdateNew(c echo.Context) error {
+	patch, err := readMergePatch(c)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	if err = h.service.UpdateNew(currentUser(c), c.Param("slug"), patch); err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.String(http.StatusOK, "new updated")
+}
+
+func (h *Handler) DeleteNew(c echo.Context) error {
+	if err := h.service.DeleteNew(currentUser(c), c.Param("slug")); err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/service"
+
+	"github.com/osamikoyo/dark-fantasy-land/pkg/imaging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// objectName keys uploaded files by the owning document id, so two users
+// uploading "cover.jpg" never overwrite each other. The extension follows
+// the sniffed format, not the name the client sent.
+func objectName(id primitive.ObjectID, info imaging.Info) string {
+	return id.Hex() + info.Extension()
+}
+
+// formStatus is the status of a multipart form that could not be read, a
+// body cut off by LimitUpload is too large rather than malformed.
+func formStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	return http.StatusBadRequest
+}
+
+// checkImage validates an upload before any of it is stored.
+func (h *Handler) checkImage(file *multipart.FileHeader) (imaging.Info, error) {
+	src, err := file.Open()
+	if err != nil {
+		return imaging.Info{}, imaging.ErrInvalidImage
+	}
+	defer src.Close()
+
+	return imaging.Validate(src, file.Size, imaging.Limits{
+		MaxBytes:  h.cfg.MaxUploadBytes,
+		MaxPixels: h.cfg.MaxUploadPixels,
+	})
+}
+
+// readImage validates an upload and strips its metadata, what gets stored
+// is the returned data, never the bytes the client sent.
+func (h *Handler) readImage(file *multipart.FileHeader) (imaging.Clean, error) {
+	info, err := h.checkImage(file)
+	if err != nil {
+		return imaging.Clean{}, err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return imaging.Clean{}, imaging.ErrInvalidImage
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return imaging.Clean{}, imaging.ErrInvalidImage
+	}
+
+	return imaging.Strip(data, info)
+}
+
+// credits copies the metadata fields the deployment keeps, nil when the
+// upload had none of them.
+func (h *Handler) credits(meta imaging.Metadata) *entity.Credits {
+	kept := meta.Keep(h.cfg.KeepMetadata)
+	if kept.Artist == "" && kept.Copyright == "" {
+		return nil
+	}
+
+	return &entity.Credits{Artist: kept.Artist, Copyright: kept.Copyright}
+}
+
+// hashImage is the perceptual hash of an upload that passed readImage.
+func hashImage(data []byte) (string, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", imaging.ErrInvalidImage
+	}
+
+	return imaging.FormatHash(imaging.DHash(img)), nil
+}
+
+// createFailed drops the objects stored for content that could not be
+// created and answers the error, a duplicate links to what it repeats.
+func (h *Handler) createFailed(c echo.Context, err error, bucket string, names ...string) error {
+	for _, name := range names {
+		if name != "" {
+			_ = h.storage.RemoveFile(name, bucket)
+		}
+	}
+
+	var duplicate *service.DuplicateError
+	if errors.As(err, &duplicate) {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error":    err.Error(),
+			"original": "/" + duplicate.Original.Kind + "/" + duplicate.Original.Slug,
+		})
+	}
+
+	return c.String(errorStatus(err), err.Error())
+}
@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/pagination"
+)
+
+func pageRequest(c echo.Context) (pagination.Request, error) {
+	return pagination.NewRequest(
+		c.QueryParam("sort"),
+		c.QueryParam("cursor"),
+		c.QueryParam("limit"),
+	)
+}
+
+// rankedPageRequest is pageRequest for lists ranked by distance, they have
+// only the one order.
+func rankedPageRequest(c echo.Context) (pagination.Request, error) {
+	return pagination.NewRankedRequest(
+		c.QueryParam("cursor"),
+		c.QueryParam("limit"),
+	)
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+
+	"github.com/bytedance/sonic"
+	"github.com/labstack/echo/v4"
+	"github.com/osamikoyo/dark-fantasy-land/internal/service"
+)
+
+const MIMEMergePatch = "application/merge-patch+json"
+
+var errUnsupportedPatch = errors.New("patch body must be application/merge-patch+json or application/json")
+
+// readMergePatch decodes an RFC 7396 merge patch document from the body.
+func readMergePatch(c echo.Context) (map[string]interface{}, error) {
+	mediaType, _, err := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if err != nil || (mediaType != MIMEMergePatch && mediaType != echo.MIMEApplicationJSON) {
+		return nil, errUnsupportedPatch
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidInput, err)
+	}
+
+	var patch map[string]interface{}
+
+	if err = sonic.Unmarshal(body, &patch); err != nil {
+		return nil, fmt.Errorf("%w: %v", service.ErrInvalidInput, err)
+	}
+
+	return patch, nil
+}
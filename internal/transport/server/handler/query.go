@@ -0,0 +1,276 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+)
+
+const dateLayout = "2006-01-02"
+
+// Image sizes asked for with ?w= and ?h=, without either a 1080p screen is
+// assumed.
+const (
+	DefaultImageWidth  = 1920
+	DefaultImageHeight = 1080
+	MaxImageSide       = 16384
+)
+
+var (
+	errBadLimit     = errors.New("bad limit")
+	errBadImageSize = errors.New("bad image size")
+	errBadRating    = errors.New("unknown rating")
+)
+
+// topics accepts both repeated ?topic= params and comma separated lists.
+func topics(c echo.Context) []string {
+	return listParam(c, "topic")
+}
+
+func listParam(c echo.Context, name string) []string {
+	var list []string
+
+	for _, value := range c.QueryParams()[name] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+
+	return list
+}
+
+// formList is listParam for the fields of a form.
+func formList(c echo.Context, name string) ([]string, error) {
+	params, err := c.FormParams()
+	if err != nil {
+		return nil, err
+	}
+
+	var list []string
+
+	for _, value := range params[name] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+
+	return list, nil
+}
+
+// ratingValue reads a rating by name, the same way a patch takes it. Left
+// out it is all-ages.
+func ratingValue(name string) (uint8, error) {
+	if name == "" {
+		return entity.RatingAllAges, nil
+	}
+
+	rating, ok := entity.ParseRating(name)
+	if !ok {
+		return 0, errBadRating
+	}
+
+	return rating, nil
+}
+
+func publishedRange(c echo.Context) (query.TimeRange, error) {
+	var (
+		published query.TimeRange
+		err       error
+	)
+
+	if published.From, err = parseTime(c.QueryParam("from"), false); err != nil {
+		return query.TimeRange{}, fmt.Errorf("bad from: %w", err)
+	}
+
+	if published.To, err = parseTime(c.QueryParam("to"), true); err != nil {
+		return query.TimeRange{}, fmt.Errorf("bad to: %w", err)
+	}
+
+	return published, nil
+}
+
+// parseTime reads RFC3339 or a bare date, a bare date used as an upper bound
+// covers the whole day.
+func parseTime(value string, upper bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return nil, err
+	}
+
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return &t, nil
+}
+
+func articleQuery(c echo.Context) (query.ArticleQuery, error) {
+	published, err := publishedRange(c)
+	if err != nil {
+		return query.ArticleQuery{}, err
+	}
+
+	maxRating, err := viewerRating(c)
+	if err != nil {
+		return query.ArticleQuery{}, err
+	}
+
+	return query.ArticleQuery{
+		Author:    c.QueryParam("author"),
+		Title:     c.QueryParam("title"),
+		Topics:    topics(c),
+		Published: published,
+		MaxRating: &maxRating,
+	}, nil
+}
+
+func memQuery(c echo.Context) (query.MemQuery, error) {
+	published, err := publishedRange(c)
+	if err != nil {
+		return query.MemQuery{}, err
+	}
+
+	maxRating, err := viewerRating(c)
+	if err != nil {
+		return query.MemQuery{}, err
+	}
+
+	return query.MemQuery{
+		Author:    c.QueryParam("author"),
+		Topics:    topics(c),
+		Published: published,
+		MaxRating: &maxRating,
+	}, nil
+}
+
+func newQuery(c echo.Context) (query.NewQuery, error) {
+	published, err := publishedRange(c)
+	if err != nil {
+		return query.NewQuery{}, err
+	}
+
+	maxRating, err := viewerRating(c)
+	if err != nil {
+		return query.NewQuery{}, err
+	}
+
+	return query.NewQuery{
+		Author:    c.QueryParam("author"),
+		Title:     c.QueryParam("title"),
+		Topic:     c.QueryParam("topic"),
+		Published: published,
+		MaxRating: &maxRating,
+	}, nil
+}
+
+func wallpaperQuery(c echo.Context) (query.WallpaperQuery, error) {
+	published, err := publishedRange(c)
+	if err != nil {
+		return query.WallpaperQuery{}, err
+	}
+
+	maxRating, err := viewerRating(c)
+	if err != nil {
+		return query.WallpaperQuery{}, err
+	}
+
+	return query.WallpaperQuery{
+		Author:    c.QueryParam("author"),
+		Topic:     c.QueryParam("topic"),
+		Published: published,
+		MaxRating: &maxRating,
+		NearColor: c.QueryParam("color"),
+	}, nil
+}
+
+func searchQuery(c echo.Context) (query.SearchQuery, error) {
+	maxRating, err := viewerRating(c)
+	if err != nil {
+		return query.SearchQuery{}, err
+	}
+
+	q := query.SearchQuery{
+		Text:      c.QueryParam("q"),
+		Types:     listParam(c, "type"),
+		Topics:    topics(c),
+		MaxRating: maxRating,
+	}
+
+	if limit := c.QueryParam("limit"); limit != "" {
+		n, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || n < 1 {
+			return query.SearchQuery{}, errBadLimit
+		}
+
+		q.Limit = n
+	}
+
+	return q, nil
+}
+
+// imageSize reads the size the client wants an image at, a missing side is
+// left unconstrained.
+func imageSize(c echo.Context) (int, int, error) {
+	w, h := c.QueryParam("w"), c.QueryParam("h")
+	if w == "" && h == "" {
+		return DefaultImageWidth, DefaultImageHeight, nil
+	}
+
+	width, err := imageSide(w)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	height, err := imageSide(h)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return width, height, nil
+}
+
+func imageSide(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 || n > MaxImageSide {
+		return 0, errBadImageSize
+	}
+
+	return n, nil
+}
+
+// limitParam reads ?limit=, zero when it is missing.
+func limitParam(c echo.Context) (int, error) {
+	limit := c.QueryParam("limit")
+	if limit == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 {
+		return 0, errBadLimit
+	}
+
+	return n, nil
+}
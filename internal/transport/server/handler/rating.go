@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/osamikoyo/dark-fantasy-land/internal/service"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/imaging"
+)
+
+// HeaderMaxRating lets a client pick its rating limit per request, it
+// overrides the limit stored in the viewer's profile.
+const HeaderMaxRating = "X-Max-Rating"
+
+func viewerRating(c echo.Context) (uint8, error) {
+	return service.ViewerRating(currentUser(c), c.Request().Header.Get(HeaderMaxRating))
+}
+
+// serveImage streams the object, or a blurred preview of it when rating is
+// above what the viewer accepts.
+func (h *Handler) serveImage(c echo.Context, bucket, name string, rating uint8) error {
+	maxRating, err := viewerRating(c)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	if rating > maxRating {
+		return h.serveBlurred(c, bucket, name)
+	}
+
+	obj, err := h.storage.DownloadFile(name, bucket)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.Stream(http.StatusOK, "application/octet-stream", obj)
+}
+
+// serveBlurred renders the preview on first request and keeps it in the
+// previews bucket for the next ones.
+func (h *Handler) serveBlurred(c echo.Context, bucket, name string) error {
+	preview := service.PreviewKey(bucket, name)
+
+	exists, err := h.storage.ObjectExists(preview, h.cfg.MinioBuckets.Previews)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if exists {
+		obj, err := h.storage.DownloadFile(preview, h.cfg.MinioBuckets.Previews)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+
+		return c.Stream(http.StatusOK, "image/jpeg", obj)
+	}
+
+	obj, err := h.storage.DownloadFile(name, bucket)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	defer obj.Close()
+
+	data, err := imaging.Blur(obj)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	// a failed upload only means the next request renders it again.
+	_ = h.storage.PutBytes(data, h.cfg.MinioBuckets.Previews, preview, "image/jpeg")
+
+	return c.Blob(http.StatusOK, "image/jpeg", data)
+}
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) Search(c echo.Context) error {
+	q, err := searchQuery(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	result, err := h.service.Search(q)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type registerRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type profileRequest struct {
+	DisplayName *string `json:"display_name"`
+	Bio         *string `json:"bio"`
+	MaxRating   *string `json:"max_rating"`
+}
+
+func (h *Handler) RegisterUser(c echo.Context) error {
+	var req registerRequest
+
+	if err := c.Bind(&req); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	user, err := h.service.RegisterUser(req.Username, req.Email, req.Password)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, user)
+}
+
+func (h *Handler) GetUser(c echo.Context) error {
+	username := c.Param("username")
+
+	profile, err := h.service.Profile(currentUser(c), username)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(http.StatusOK, profile)
+}
+
+func (h *Handler) UpdateProfile(c echo.Context) error {
+	username := c.Param("username")
+	if currentUser(c).Username != username {
+		return c.String(http.StatusForbidden, "can not edit another user's profile")
+	}
+
+	var req profileRequest
+
+	if err := c.Bind(&req); err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	update := make(map[string]interface{})
+	if req.DisplayName != nil {
+		update["display_name"] = *req.DisplayName
+	}
+	if req.Bio != nil {
+		update["bio"] = *req.Bio
+	}
+	if req.MaxRating != nil {
+		update["max_rating"] = *req.MaxRating
+	}
+
+	if err := h.service.UpdateProfile(username, update); err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.String(http.StatusOK, "profile updated")
+}
+
+func (h *Handler) UploadAvatar(c echo.Context) error {
+	username := c.Param("username")
+	if currentUser(c).Username != username {
+		return c.String(http.StatusForbidden, "can not edit another user's profile")
+	}
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		return c.String(formStatus(err), err.Error())
+	}
+
+	upload, err := h.readImage(file)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	avatar := username + upload.Info.Extension()
+
+	if err = h.storage.PutBytes(upload.Data, h.cfg.MinioBuckets.Avatars, avatar, upload.Info.ContentType); err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	if err = h.service.SetAvatar(username, avatar); err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.String(http.StatusOK, "avatar updated")
+}
+
+func (h *Handler) GetAvatar(c echo.Context) error {
+	user, err := h.service.GetUser(c.Param("username"))
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	if user.Avatar == "" {
+		return c.String(http.StatusNotFound, "avatar not set")
+	}
+
+	avatar, err := h.storage.DownloadFile(user.Avatar, h.cfg.MinioBuckets.Avatars)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.Stream(http.StatusOK, "application/octet-stream", avatar)
+}
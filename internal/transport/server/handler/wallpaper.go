@@ -1,91 +1,225 @@
 package handler
 
 import (
+	"bytes"
+	"fmt"
+	"image"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/service"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/imaging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func (h *Handler) CreateWallpaper(c echo.Context) error {
-	var wallpaper entity.Wallpaper
-
-	if err := c.Bind(&wallpaper); err != nil {
+	rating, err := ratingValue(c.FormValue("rating"))
+	if err != nil {
 		return c.String(http.StatusBadRequest, err.Error())
 	}
 
+	// the entity has no form tags, multipart fields are read one by one.
+	wallpaper := entity.Wallpaper{
+		Topic:     c.FormValue("topic"),
+		Rating:    rating,
+		Watermark: c.FormValue("watermark"),
+	}
+
 	file, err := c.FormFile("image")
 	if err != nil {
-		return c.String(http.StatusBadRequest, err.Error())
+		return c.String(formStatus(err), err.Error())
 	}
 
-	if err = h.storage.UploadFile(file, h.cfg.MinioBuckets.WallpaperFull); err != nil {
-		return c.String(http.StatusInternalServerError, err.Error())
+	upload, err := h.readImage(file)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	if wallpaper.Hash, err = hashImage(upload.Data); err != nil {
+		return c.String(errorStatus(err), err.Error())
 	}
 
-	if err = h.storage.UploadAndCommpress(file, h.cfg.MinioBuckets.WallpaperWatch); err != nil {
+	wallpaper.ID = primitive.NewObjectID()
+	wallpaper.ImageName = objectName(wallpaper.ID, upload.Info)
+	wallpaper.Resolution = fmt.Sprintf("%dx%d", upload.Info.Width, upload.Info.Height)
+	wallpaper.Author = currentUser(c).Username
+	wallpaper.Timestamp = time.Now()
+	wallpaper.Credits = h.credits(upload.Meta)
+
+	if err = h.storage.PutBytes(upload.Data, h.cfg.MinioBuckets.WallpaperFull, wallpaper.ImageName, upload.Info.ContentType); err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
 	if err = h.service.CreateWallpaper(&wallpaper); err != nil {
-		return c.String(http.StatusInternalServerError, err.Error())
+		return h.createFailed(c, err, h.cfg.MinioBuckets.WallpaperFull, wallpaper.ImageName)
 	}
 
-	return c.String(http.StatusCreated, "wallpaper created")
+	return c.JSON(http.StatusCreated, wallpaper)
 }
 
 func (h *Handler) GetWallpapers(c echo.Context) error {
-	params := []string{"author", "title", "image_name", "timestamp"}
+	q, err := wallpaperQuery(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
 
-	filter := make(map[string]interface{})
-	for _, p := range params {
-		filter[p] = c.Param(p)
+	paging := pageRequest
+	if q.NearColor != "" {
+		paging = rankedPageRequest
 	}
 
-	for key, value := range filter {
-		if value == "" {
-			delete(filter, key)
-		}
+	page, err := paging(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
 	}
 
-	wallpapers, err := h.service.GetManyWallpapers(filter)
+	wallpapers, err := h.service.GetManyWallpapers(q, page)
 	if err != nil {
-		return c.String(http.StatusInternalServerError, err.Error())
+		return c.String(errorStatus(err), err.Error())
 	}
 
 	return c.JSON(http.StatusOK, wallpapers)
 }
 
 func (h *Handler) GetWallpaperInfo(c echo.Context) error {
-	title := c.Param("title")
-	image_name := c.Param("image_name")
-
-	wallpaper, err := h.service.GetOneWallpaper(image_name, title)
+	wallpaper, err := h.service.GetOneWallpaper(currentUser(c), c.Param("slug"))
 	if err != nil {
-		return c.String(http.StatusInternalServerError, err.Error())
+		return c.String(errorStatus(err), err.Error())
 	}
 
 	return c.JSON(http.StatusOK, wallpaper)
 }
 
 func (h *Handler) GetWallpaperImage(c echo.Context) error {
-	image_name := c.Param("image_name")
+	wallpaper, err := h.service.GetOneWallpaper(currentUser(c), c.Param("slug"))
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
 
-	wallpaper, err := h.storage.DownloadFile(image_name, h.cfg.MinioBuckets.WallpaperWatch)
+	width, height, err := imageSize(c)
 	if err != nil {
-		return c.String(http.StatusInternalServerError, err.Error())
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	rendition, ok := service.BestRendition(wallpaper.Renditions, width, height)
+	if !ok {
+		// wallpapers uploaded before renditions only have one watch copy,
+		// those still being processed only the original.
+		if wallpaper.Processing == "" {
+			return h.serveImage(c, h.cfg.MinioBuckets.WallpaperWatch, wallpaper.ImageName, wallpaper.Rating)
+		}
+
+		return h.serveImage(c, h.cfg.MinioBuckets.WallpaperFull, wallpaper.ImageName, wallpaper.Rating)
+	}
+
+	return h.serveImage(c, h.cfg.MinioBuckets.WallpaperWatch, rendition.Key, wallpaper.Rating)
+}
+
+func (h *Handler) GetWallpaperProcessing(c echo.Context) error {
+	event, err := h.service.WallpaperProcessing(currentUser(c), c.Param("slug"))
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(http.StatusOK, event)
+}
+
+func (h *Handler) GetSimilarWallpapers(c echo.Context) error {
+	maxRating, err := viewerRating(c)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	limit, err := limitParam(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, err.Error())
+	}
+
+	similar, err := h.service.SimilarWallpapers(currentUser(c), c.Param("slug"), maxRating, limit)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
 	}
 
-	return c.Stream(http.StatusOK, "application/octet-stream", wallpaper)
+	return c.JSON(http.StatusOK, similar)
 }
 
 func (h *Handler) DownloadWallpaper(c echo.Context) error {
-	image_name := c.Param("image_name")
+	wallpaper, err := h.service.GetOneWallpaper(currentUser(c), c.Param("slug"))
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
 
-	wallpaper, err := h.storage.DownloadFile(image_name, h.cfg.MinioBuckets.WallpaperFull)
+	maxRating, err := viewerRating(c)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	// the full image has no blurred variant, it is only served as is.
+	if wallpaper.Rating > maxRating {
+		return c.String(errorStatus(service.ErrAboveRating), service.ErrAboveRating.Error())
+	}
+
+	if wallpaper.Watermark != "" {
+		marked, err := h.service.WatermarkedWallpaper(currentUser(c), wallpaper)
+		if err != nil {
+			return c.String(errorStatus(err), err.Error())
+		}
+		defer marked.Close()
+
+		return c.Stream(http.StatusOK, "image/jpeg", marked)
+	}
+
+	obj, err := h.storage.DownloadFile(wallpaper.ImageName, h.cfg.MinioBuckets.WallpaperFull)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
-	return c.Stream(http.StatusOK, "application/octet-stream", wallpaper)
+	return c.Stream(http.StatusOK, "application/octet-stream", obj)
+}
+
+func (h *Handler) VerifyWatermark(c echo.Context) error {
+	file, err := c.FormFile("image")
+	if err != nil {
+		return c.String(formStatus(err), err.Error())
+	}
+
+	upload, err := h.readImage(file)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(upload.Data))
+	if err != nil {
+		return c.String(errorStatus(imaging.ErrInvalidImage), imaging.ErrInvalidImage.Error())
+	}
+
+	match, err := h.service.VerifyWatermark(currentUser(c), img)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.JSON(http.StatusOK, match)
+}
+
+func (h *Handler) UpdateWallpaper(c echo.Context) error {
+	patch, err := readMergePatch(c)
+	if err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	if err = h.service.UpdateWallpaper(currentUser(c), c.Param("slug"), patch); err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.String(http.StatusOK, "wallpaper updated")
+}
+
+func (h *Handler) DeleteWallpaper(c echo.Context) error {
+	if err := h.service.DeleteWallpaper(currentUser(c), c.Param("slug")); err != nil {
+		return c.String(errorStatus(err), err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
 }
@@ -1,4 +1,343 @@
 package server
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/nats-io/nats.go"
+	"github.com/osamikoyo/dark-fantasy-land/internal/config"
+	"github.com/osamikoyo/dark-fantasy-land/internal/repository"
+	"github.com/osamikoyo/dark-fantasy-land/internal/service"
+	"github.com/osamikoyo/dark-fantasy-land/internal/transport/server/handler"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/casher"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/censor"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/consumer"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/logger"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/producer"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/retrier"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/storage"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/token"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const (
+	ConnectAttempts = 3
+	ConnectSleep    = 5
+	ServiceTimeout  = 5 * time.Second
+	StorageTimeout  = 30 * time.Second
+	ShutdownTimeout = 15 * time.Second
+	OutboxInterval  = 2 * time.Second
+	DatabaseName    = "dark-fantasy"
+)
+
 type Server struct {
+	echo   *echo.Echo
+	core   *service.Service
+	cfg    *config.Config
+	logger *logger.Logger
+
+	consumer    *consumer.Consumer
+	mongoClient *mongo.Client
+	redisClient *redis.Client
+	natsConn    *nats.Conn
+	natsClosed  chan struct{}
+}
+
+func NewServer(cfg *config.Config, logger *logger.Logger) (*Server, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("validate config: %w", err)
+	}
+
+	mongoClient, err := retrier.Connect(ConnectAttempts, ConnectSleep, func() (*mongo.Client, error) {
+		client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(cfg.MongoUrl))
+		if err != nil {
+			return nil, err
+		}
+
+		return client, client.Ping(context.Background(), nil)
+	})
+	if err != nil {
+		logger.Error("failed connect to mongodb", zap.Error(err))
+
+		return nil, fmt.Errorf("connect to mongodb: %w", err)
+	}
+
+	logger.Info("successfully connected to mongo db")
+
+	redisClient, err := retrier.Connect(ConnectAttempts, ConnectSleep, func() (*redis.Client, error) {
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisUrl,
+			Password: "",
+			DB:       0,
+		})
+
+		return client, client.Ping(context.Background()).Err()
+	})
+	if err != nil {
+		logger.Error("failed connect to redis", zap.Error(err))
+
+		return nil, fmt.Errorf("connect to redis: %w", err)
+	}
+
+	logger.Info("successfully connected to redis")
+
+	natsClosed := make(chan struct{})
+
+	natsConn, err := retrier.Connect(ConnectAttempts, ConnectSleep, func() (*nats.Conn, error) {
+		return nats.Connect(cfg.NatsUrl, nats.ClosedHandler(func(*nats.Conn) {
+			close(natsClosed)
+		}))
+	})
+	if err != nil {
+		logger.Error("failed connect to nats", zap.Error(err))
+
+		return nil, fmt.Errorf("connect to nats: %w", err)
+	}
+
+	logger.Info("successfully connected to nats")
+
+	minioClient, err := minio.New(cfg.MinioUrl, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.MinioAccessKey, cfg.MinioSecretKey, ""),
+		Secure: cfg.MinioSSL,
+	})
+	if err != nil {
+		logger.Error("failed create minio client", zap.Error(err))
+
+		return nil, fmt.Errorf("create minio client: %w", err)
+	}
+
+	fileStorage := storage.NewStorage(minioClient, logger, StorageTimeout)
+
+	if err = retrier.Do(ConnectAttempts, ConnectSleep*time.Second, func() error {
+		return fileStorage.EnsureBuckets(
+			cfg.MinioBuckets.WallpaperFull,
+			cfg.MinioBuckets.WallpaperWatch,
+			cfg.MinioBuckets.Mems,
+			cfg.MinioBuckets.Avatars,
+			cfg.MinioBuckets.Previews,
+			cfg.MinioBuckets.MemTemplates,
+		)
+	}); err != nil {
+		return nil, fmt.Errorf("ensure minio buckets: %w", err)
+	}
+
+	logger.Info("successfully connected to minio")
+
+	repo, err := repository.NewRepository(mongoClient.Database(DatabaseName), logger)
+	if err != nil {
+		logger.Error("failed create repository", zap.Error(err))
+
+		return nil, err
+	}
+
+	indexCtx, cancel := context.WithTimeout(context.Background(), ServiceTimeout)
+	defer cancel()
+
+	if err = repo.CreateIndexes(indexCtx); err != nil {
+		return nil, err
+	}
+
+	cash := casher.NewCasher(redisClient, logger)
+	publisher := producer.NewProducer(natsConn, logger)
+
+	if err = publisher.EnsureStream(); err != nil {
+		return nil, fmt.Errorf("ensure censor stream: %w", err)
+	}
+
+	var sender service.Sender = publisher
+
+	if cfg.CensorMode == config.CensorLocal {
+		rules, err := censor.LoadRules(cfg.CensorRules)
+		if err != nil {
+			return nil, err
+		}
+
+		if sender, err = censor.New(rules, publisher, logger); err != nil {
+			return nil, err
+		}
+
+		logger.Info("using local censor", zap.String("rules", cfg.CensorRules))
+	}
+
+	tokens := token.NewManager(cfg.JWTSecret, cfg.AccessTTL, cfg.RefreshTTL)
+
+	core := service.NewService(repo, cash, sender, tokens, fileStorage, cfg.MinioBuckets, cfg.Duplicates, cfg.Watermark, ServiceTimeout)
+
+	if err = core.BootstrapAdmins(cfg.Admins); err != nil {
+		logger.Error("failed bootstrap admins", zap.Strings("admins", cfg.Admins), zap.Error(err))
+
+		return nil, err
+	}
+
+	subscriber := consumer.NewConsumer(logger, core, natsConn)
+
+	if err = subscriber.SubscribeAll(); err != nil {
+		return nil, fmt.Errorf("subscribe to censor verdicts: %w", err)
+	}
+
+	if err = subscriber.SubscribeImages(); err != nil {
+		return nil, fmt.Errorf("subscribe to image jobs: %w", err)
+	}
+
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+
+	handler.NewHandler(core, fileStorage, cfg).RegisterRouters(e)
+
+	return &Server{
+		echo:        e,
+		core:        core,
+		cfg:         cfg,
+		consumer:    subscriber,
+		logger:      logger,
+		mongoClient: mongoClient,
+		redisClient: redisClient,
+		natsConn:    natsConn,
+		natsClosed:  natsClosed,
+	}, nil
+}
+
+// Run serves HTTP until ctx is cancelled and then shuts the server down.
+func (s *Server) Run(ctx context.Context) error {
+	addr := net.JoinHostPort(s.cfg.Host, s.cfg.Port)
+
+	relayCtx, stopRelay := context.WithCancel(ctx)
+	defer stopRelay()
+
+	relayDone := make(chan struct{})
+
+	go func() {
+		defer close(relayDone)
+
+		s.runOutboxRelay(relayCtx)
+	}()
+
+	workersDone := make(chan struct{})
+
+	go func() {
+		defer close(workersDone)
+
+		s.core.RunImageWorkers(relayCtx, s.cfg.ImageWorkers)
+	}()
+
+	fetchDone := make(chan struct{})
+
+	go func() {
+		defer close(fetchDone)
+
+		s.consumer.FetchImages(relayCtx, s.cfg.ImageWorkers)
+	}()
+
+	errChan := make(chan error, 1)
+
+	go func() {
+		s.logger.Info("starting http server", zap.String("addr", addr))
+
+		if err := s.echo.Start(addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errChan <- err
+		}
+
+		close(errChan)
+	}()
+
+	select {
+	case err := <-errChan:
+		if err != nil {
+			s.logger.Error("http server stopped", zap.Error(err))
+		}
+
+		stopRelay()
+		<-relayDone
+		<-fetchDone
+		<-workersDone
+
+		s.shutdown()
+
+		return err
+	case <-ctx.Done():
+		s.logger.Info("shutdown signal received")
+	}
+
+	// the relay and the image workers use nats, mongo and minio, shutdown
+	// closes the first two.
+	<-relayDone
+	<-fetchDone
+	<-workersDone
+
+	return s.shutdown()
+}
+
+// runOutboxRelay publishes the outbox every OutboxInterval until ctx is
+// done.
+func (s *Server) runOutboxRelay(ctx context.Context) {
+	ticker := time.NewTicker(OutboxInterval)
+	defer ticker.Stop()
+
+	for {
+		sent, err := s.core.RelayOutbox(ctx)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Warn("outbox relay stopped early", zap.Int("sent", sent), zap.Error(err))
+		} else if sent > 0 {
+			s.logger.Debug("outbox relayed", zap.Int("sent", sent))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	var errs []error
+
+	if err := s.echo.Shutdown(ctx); err != nil {
+		s.logger.Error("failed shutdown http server", zap.Error(err))
+		errs = append(errs, err)
+	}
+
+	if err := s.natsConn.Drain(); err != nil {
+		s.logger.Error("failed drain nats connection", zap.Error(err))
+		errs = append(errs, err)
+	} else {
+		select {
+		case <-s.natsClosed:
+		case <-ctx.Done():
+			s.logger.Warn("nats drain timed out")
+			errs = append(errs, ctx.Err())
+		}
+	}
+
+	if err := s.redisClient.Close(); err != nil {
+		s.logger.Error("failed close redis client", zap.Error(err))
+		errs = append(errs, err)
+	}
+
+	if err := s.mongoClient.Disconnect(ctx); err != nil {
+		s.logger.Error("failed disconnect from mongodb", zap.Error(err))
+		errs = append(errs, err)
+	}
+
+	s.logger.Info("server stopped")
+
+	// syncing a console core fails on ttys and pipes, that error says
+	// nothing about whether the file log was flushed.
+	_ = logger.Sync()
+
+	return errors.Join(errs...)
 }
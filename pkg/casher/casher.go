@@ -2,12 +2,24 @@ package casher
 
 import (
 	"errors"
+	"time"
 
 	"github.com/osamikoyo/dark-fantasy-land/pkg/logger"
 	"github.com/redis/go-redis/v9"
 )
 
-var NIL_INPUT_ERROR = errors.New("input value in nil")
+const (
+	EntityTTL = 24 * time.Hour
+
+	// SearchTTL is short because search results are not invalidated when
+	// the content they list changes.
+	SearchTTL = 5 * time.Minute
+)
+
+var (
+	NIL_INPUT_ERROR = errors.New("input value in nil")
+	NOT_FOUND_ERROR = errors.New("value not found in cash")
+)
 
 type Casher struct {
 	client *redis.Client
@@ -0,0 +1,140 @@
+package casher
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bytedance/sonic"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// setEntity stores a document under its id and points its slug at that id,
+// so a lookup by either reference hits the same cached value.
+func (c *Casher) setEntity(ctx context.Context, kind string, id primitive.ObjectID, slug string, value interface{}) error {
+	if id.IsZero() || slug == "" {
+		return NIL_INPUT_ERROR
+	}
+
+	payload, err := sonic.Marshal(value)
+	if err != nil {
+		c.logger.Error("failed marshal value for cash",
+			zap.String("kind", kind),
+			zap.Error(err))
+
+		return err
+	}
+
+	key := newEntityKey(kind, id.Hex())
+
+	_, err = c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, payload, EntityTTL)
+		pipe.Set(ctx, newSlugKey(kind, slug), id.Hex(), EntityTTL)
+
+		return nil
+	})
+	if err != nil {
+		c.logger.Error("failed add value to cash",
+			zap.String("key", key),
+			zap.Error(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// getEntity resolves ref, an object id in hex or a slug, into out.
+func (c *Casher) getEntity(ctx context.Context, kind, ref string, out interface{}) error {
+	if ref == "" {
+		return NIL_INPUT_ERROR
+	}
+
+	id := ref
+	if !primitive.IsValidObjectID(ref) {
+		var err error
+
+		id, err = c.client.Get(ctx, newSlugKey(kind, ref)).Result()
+		if err != nil {
+			return c.missOrError(kind, ref, err)
+		}
+	}
+
+	return c.getValue(ctx, kind, newEntityKey(kind, id), out)
+}
+
+// setValue stores value as json under key for EntityTTL.
+func (c *Casher) setValue(ctx context.Context, kind, key string, value interface{}) error {
+	payload, err := sonic.Marshal(value)
+	if err != nil {
+		c.logger.Error("failed marshal value for cash",
+			zap.String("kind", kind),
+			zap.Error(err))
+
+		return err
+	}
+
+	if err = c.client.Set(ctx, key, payload, EntityTTL).Err(); err != nil {
+		c.logger.Error("failed add value to cash",
+			zap.String("key", key),
+			zap.Error(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// getValue decodes the json stored under key into out.
+func (c *Casher) getValue(ctx context.Context, kind, key string, out interface{}) error {
+	c.logger.Debug("fetching value from cash", zap.String("key", key))
+
+	payload, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		return c.missOrError(kind, key, err)
+	}
+
+	if err = sonic.Unmarshal(payload, out); err != nil {
+		c.logger.Error("failed decode value from cash",
+			zap.String("key", key),
+			zap.Error(err))
+
+		return err
+	}
+
+	return nil
+}
+
+func (c *Casher) deleteEntity(ctx context.Context, kind string, id primitive.ObjectID, slug string) error {
+	if id.IsZero() || slug == "" {
+		return NIL_INPUT_ERROR
+	}
+
+	key := newEntityKey(kind, id.Hex())
+
+	c.logger.Debug("deleting value from cash", zap.String("key", key))
+
+	if err := c.client.Del(ctx, key, newSlugKey(kind, slug)).Err(); err != nil {
+		c.logger.Error("failed delete value from cash",
+			zap.String("key", key),
+			zap.Error(err))
+
+		return err
+	}
+
+	return nil
+}
+
+func (c *Casher) missOrError(kind, ref string, err error) error {
+	if errors.Is(err, redis.Nil) {
+		return NOT_FOUND_ERROR
+	}
+
+	c.logger.Error("failed get value from cash",
+		zap.String("kind", kind),
+		zap.String("ref", ref),
+		zap.Error(err))
+
+	return err
+}
@@ -1,19 +1,49 @@
 package casher
 
-import "fmt"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
 
-func newArticleKey(author, title string) string {
-	return fmt.Sprintf("article:%s:%s", author, title)
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+)
+
+const (
+	articleKind   = "article"
+	wallpaperKind = "wallpaper"
+	newKind       = "new"
+	memKind       = "mem"
+)
+
+func newEntityKey(kind, id string) string {
+	return fmt.Sprintf("%s:%s", kind, id)
+}
+
+func newSlugKey(kind, slug string) string {
+	return fmt.Sprintf("%s:slug:%s", kind, slug)
 }
 
-func newWallpaperKey(imageName, author string) string {
-	return fmt.Sprintf("wallpaper:%s:%s", imageName, author)
+func newUserKey(username string) string {
+	return fmt.Sprintf("user:%s", username)
 }
 
-func newNewKey(title, author string) string {
-	return fmt.Sprintf("new:%s:%s", author, title)
+func newRevokedTokenKey(id string) string {
+	return fmt.Sprintf("revoked:%s", id)
 }
 
-func newMemKey(imageName, title string) string {
-	return fmt.Sprintf("mem:%s:%s", imageName, title)
+// newSearchKey hashes a normalized query, free text has no length limit and
+// may hold anything, so it never goes into the key as is.
+func newSearchKey(q query.SearchQuery) string {
+	q = q.Normalize()
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%d\x00%d",
+		q.Text,
+		strings.Join(q.Types, ","),
+		strings.Join(q.Topics, ","),
+		q.Limit,
+		q.MaxRating,
+	)))
+
+	return fmt.Sprintf("search:%s", hex.EncodeToString(sum[:]))
 }
@@ -0,0 +1,66 @@
+package casher
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bytedance/sonic"
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/query"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+func (c *Casher) AddSearchToCash(ctx context.Context, q query.SearchQuery, result *entity.SearchResult) error {
+	if result == nil {
+		return NIL_INPUT_ERROR
+	}
+
+	payload, err := sonic.Marshal(result)
+	if err != nil {
+		c.logger.Error("failed marshal search result", zap.Error(err))
+
+		return err
+	}
+
+	key := newSearchKey(q)
+
+	if err = c.client.Set(ctx, key, payload, SearchTTL).Err(); err != nil {
+		c.logger.Error("failed add search result to cash",
+			zap.String("key", key),
+			zap.Error(err))
+
+		return err
+	}
+
+	return nil
+}
+
+func (c *Casher) GetSearchFromCash(ctx context.Context, q query.SearchQuery) (*entity.SearchResult, error) {
+	key := newSearchKey(q)
+
+	payload, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, NOT_FOUND_ERROR
+		}
+
+		c.logger.Error("failed get search result from cash",
+			zap.String("key", key),
+			zap.Error(err))
+
+		return nil, err
+	}
+
+	var result entity.SearchResult
+
+	if err = sonic.Unmarshal(payload, &result); err != nil {
+		c.logger.Error("failed decode search result from cash",
+			zap.String("key", key),
+			zap.Error(err))
+
+		return nil, err
+	}
+
+	return &result, nil
+}
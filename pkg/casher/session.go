@@ -0,0 +1,51 @@
+package casher
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RevokeToken marks the token id revoked until ttl passes. It reports
+// false when the token was already revoked or has expired, so of two
+// callers racing to revoke the same token only one wins.
+func (c *Casher) RevokeToken(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	if id == "" {
+		return false, NIL_INPUT_ERROR
+	}
+
+	if ttl <= 0 {
+		return false, nil
+	}
+
+	key := newRevokedTokenKey(id)
+
+	revoked, err := c.client.SetNX(ctx, key, 1, ttl).Result()
+	if err != nil {
+		c.logger.Error("failed revoke token",
+			zap.String("key", key),
+			zap.Error(err))
+		return false, err
+	}
+
+	return revoked, nil
+}
+
+func (c *Casher) IsTokenRevoked(ctx context.Context, id string) (bool, error) {
+	if id == "" {
+		return false, NIL_INPUT_ERROR
+	}
+
+	key := newRevokedTokenKey(id)
+
+	n, err := c.client.Exists(ctx, key).Result()
+	if err != nil {
+		c.logger.Error("failed check revoked token",
+			zap.String("key", key),
+			zap.Error(err))
+		return false, err
+	}
+
+	return n > 0, nil
+}
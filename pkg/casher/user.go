@@ -0,0 +1,49 @@
+package casher
+
+import (
+	"context"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"go.uber.org/zap"
+)
+
+// AddUserToCash caches user for EntityTTL. The password hash is not part of
+// its json, so it never reaches redis.
+func (c *Casher) AddUserToCash(ctx context.Context, user *entity.User) error {
+	if user == nil || user.Username == "" {
+		return NIL_INPUT_ERROR
+	}
+
+	c.logger.Debug("adding user to cash", zap.String("username", user.Username))
+
+	return c.setValue(ctx, "user", newUserKey(user.Username), user)
+}
+
+func (c *Casher) GetUserFromCash(ctx context.Context, username string) (*entity.User, error) {
+	if username == "" {
+		return nil, NIL_INPUT_ERROR
+	}
+
+	var user entity.User
+
+	if err := c.getValue(ctx, "user", newUserKey(username), &user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (c *Casher) DeleteUserFromCash(ctx context.Context, username string) error {
+	if username == "" {
+		return NIL_INPUT_ERROR
+	}
+	key := newUserKey(username)
+	_, err := c.client.Del(ctx, key).Result()
+	if err != nil {
+		c.logger.Error("failed delete user from cash",
+			zap.String("key", key),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
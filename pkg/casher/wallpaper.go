@@ -3,9 +3,7 @@ package casher
 import (
 	"context"
 
-	"github.com/mitchellh/mapstructure"
 	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
-	"go.uber.org/zap"
 )
 
 func (c *Casher) AddWallpaperToCash(ctx context.Context, wallpaper *entity.Wallpaper) error {
@@ -13,79 +11,23 @@ func (c *Casher) AddWallpaperToCash(ctx context.Context, wallpaper *entity.Wallp
 		return NIL_INPUT_ERROR
 	}
 
-	c.logger.Debug("adding wallpaper to cash", zap.Any("wallpaper", wallpaper))
-
-	key := newWallpaperKey(wallpaper.ImageName, wallpaper.Topic)
-
-	_, err := c.client.HSet(ctx, key, wallpaper).Result()
-	if err != nil {
-		c.logger.Error("failed add wallpaper to cash",
-			zap.String("key", key),
-			zap.Error(err))
-		return err
-	}
-
-	return nil
+	return c.setEntity(ctx, wallpaperKind, wallpaper.ID, wallpaper.Slug, wallpaper)
 }
 
-func (c *Casher) GetWallpaperFromCash(ctx context.Context, imageName, topic string) (*entity.Wallpaper, error) {
-	if imageName == "" || topic == "" {
-		return nil, NIL_INPUT_ERROR
-	}
-
-	key := newWallpaperKey(imageName, topic)
-
-	c.logger.Debug("fetching wallpaper", zap.String("key", key))
-
-	res, err := c.client.HGetAll(ctx, key).Result()
-	if err != nil {
-		c.logger.Error("failed get wallpaper from cash",
-			zap.String("key", key),
-			zap.Error(err))
-		return nil, err
-	}
-
+func (c *Casher) GetWallpaperFromCash(ctx context.Context, ref string) (*entity.Wallpaper, error) {
 	var wallpaper entity.Wallpaper
 
-	if err = mapstructure.Decode(res, &wallpaper); err != nil {
-		c.logger.Error("failed decode wallpaper from cash",
-			zap.Any("result", res),
-			zap.Error(err))
+	if err := c.getEntity(ctx, wallpaperKind, ref, &wallpaper); err != nil {
 		return nil, err
 	}
 
 	return &wallpaper, nil
 }
 
-func (c *Casher) UpdateWallpaperInCash(ctx context.Context, imageName, topic, key string, value interface{}) error {
-	if imageName == "" || topic == "" || key == "" {
+func (c *Casher) DeleteWallpaperFromCash(ctx context.Context, wallpaper *entity.Wallpaper) error {
+	if wallpaper == nil {
 		return NIL_INPUT_ERROR
 	}
 
-	redisKey := newWallpaperKey(imageName, topic)
-
-	_, err := c.client.HSet(ctx, redisKey, key, value).Result()
-	if err != nil {
-		c.logger.Error("failed update wallpaper in hash",
-			zap.String("key", key),
-			zap.Error(err))
-		return err
-	}
-
-	return nil
-}
-
-func (c *Casher) DeleteWallpaperFromCash(ctx context.Context, imageName, topic string) error {
-	if imageName == "" || topic == "" {
-		return NIL_INPUT_ERROR
-	}
-	key := newWallpaperKey(imageName, topic)
-	_, err := c.client.Del(ctx, key).Result()
-	if err != nil {
-		c.logger.Error("failed delete wallpaper from cash",
-			zap.String("key", key),
-			zap.Error(err))
-		return err
-	}
-	return nil
+	return c.deleteEntity(ctx, wallpaperKind, wallpaper.ID, wallpaper.Slug)
 }
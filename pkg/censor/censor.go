@@ -0,0 +1,183 @@
+package censor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bytedance/sonic"
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// VerdictPrefix turns a review subject into the one its verdicts go to.
+const VerdictPrefix = "uncensored_"
+
+// Publisher is what the censor hands its verdicts to, producer.Producer in
+// practice.
+type Publisher interface {
+	SendToCensor(string, string, interface{}) error
+}
+
+// Censor judges content in process instead of sending it to the external
+// censor. It satisfies service.Sender, so it replaces the producer as the
+// service's sender and publishes its verdicts through it.
+type Censor struct {
+	rules  *compiled
+	next   Publisher
+	logger *logger.Logger
+}
+
+// document holds the fields of any content kind the rules look at.
+type document struct {
+	ID          primitive.ObjectID
+	Title       string
+	Content     string
+	Description string
+	Topic       string
+	Topics      []string
+	Rating      uint8
+	Censor      uint8
+	Revision    int64
+}
+
+func New(rules Rules, next Publisher, logger *logger.Logger) (*Censor, error) {
+	c, err := compile(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Censor{
+		rules:  c,
+		next:   next,
+		logger: logger,
+	}, nil
+}
+
+// SendToCensor judges value right away and publishes the verdict. Messages
+// for subjects the censor does not review, such as replayed verdicts, are
+// passed on untouched. The verdict keeps id, so judging a message again
+// publishes it only once.
+func (c *Censor) SendToCensor(subject, id string, value interface{}) error {
+	if !reviewed(subject) {
+		return c.next.SendToCensor(subject, id, value)
+	}
+
+	payload, err := sonic.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	var doc document
+
+	if err = sonic.Unmarshal(payload, &doc); err != nil || doc.ID.IsZero() {
+		c.logger.Error("failed decode content for review",
+			zap.String("subject", subject),
+			zap.Error(err))
+
+		return fmt.Errorf("decode %s for review: %w", subject, err)
+	}
+
+	reasons := c.judge(subject, &doc)
+
+	verdict := entity.Request{
+		CensoredAt:  time.Now(),
+		Payload:     map[string]string{"id": doc.ID.Hex(), "revision": strconv.FormatInt(doc.Revision, 10)},
+		Censored:    len(reasons) > 0,
+		Description: strings.Join(reasons, "; "),
+	}
+
+	c.logger.Info("content reviewed",
+		zap.String("subject", subject),
+		zap.String("id", doc.ID.Hex()),
+		zap.Bool("censored", verdict.Censored),
+		zap.Strings("reasons", reasons))
+
+	return c.next.SendToCensor(VerdictPrefix+subject, id, verdict)
+}
+
+func reviewed(subject string) bool {
+	switch subject {
+	case "articles", "news", "mems", "wallpapers":
+		return true
+	default:
+		return false
+	}
+}
+
+// judge returns why doc breaks the rules, nothing when it passes.
+func (c *Censor) judge(subject string, doc *document) []string {
+	var (
+		reasons []string
+		texts   []string
+		topics  = doc.Topics
+	)
+
+	switch subject {
+	case "articles":
+		texts = []string{doc.Title, doc.Content}
+	case "news":
+		texts = []string{doc.Title, doc.Content}
+		topics = []string{doc.Topic}
+	case "mems":
+		texts = []string{doc.Description}
+	case "wallpapers":
+		topics = []string{doc.Topic}
+	}
+
+	// news keep their rating in Censor, every other kind in Rating.
+	if rating := max(doc.Rating, doc.Censor); c.rules.maxRating != nil && rating > *c.rules.maxRating {
+		reasons = append(reasons, fmt.Sprintf("rating %s is above %s",
+			entity.RatingName(rating), entity.RatingName(*c.rules.maxRating)))
+	}
+
+	if c.rules.topics != nil {
+		for _, topic := range topics {
+			// the topic is optional, only one that is set has to be allowed.
+			if topic == "" {
+				continue
+			}
+
+			if _, ok := c.rules.topics[topic]; !ok {
+				reasons = append(reasons, fmt.Sprintf("topic %q is not allowed", topic))
+			}
+		}
+	}
+
+	text := strings.Join(texts, "\n")
+
+	seen := make(map[string]struct{})
+
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), notWordRune) {
+		if _, banned := c.rules.words[word]; !banned {
+			continue
+		}
+
+		if _, ok := seen[word]; !ok {
+			seen[word] = struct{}{}
+			reasons = append(reasons, fmt.Sprintf("banned word %q", word))
+		}
+	}
+
+	for _, re := range c.rules.patterns {
+		if re.MatchString(text) {
+			reasons = append(reasons, fmt.Sprintf("matches %q", re.String()))
+		}
+	}
+
+	if c.rules.maxLinks != nil {
+		if links := len(linkPattern.FindAllStringIndex(text, -1)); links > *c.rules.maxLinks {
+			reasons = append(reasons, fmt.Sprintf("%d links, at most %d allowed", links, *c.rules.maxLinks))
+		}
+	}
+
+	return reasons
+}
+
+func notWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
@@ -0,0 +1,143 @@
+package censor
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+)
+
+func TestCompile(t *testing.T) {
+	negative, none := -1, 0
+	gore, unknown := "gore", "grim"
+
+	tests := []struct {
+		name  string
+		rules Rules
+		ok    bool
+	}{
+		{name: "defaults", rules: DefaultRules, ok: true},
+		{name: "words", rules: Rules{BannedWords: []string{"Orc", "goblin"}}, ok: true},
+		{name: "no links", rules: Rules{MaxLinks: &none}, ok: true},
+		{name: "rating", rules: Rules{MaxRating: &gore}, ok: true},
+		{name: "phrase as a word", rules: Rules{BannedWords: []string{"dark lord"}}},
+		{name: "punctuation in a word", rules: Rules{BannedWords: []string{"orc!"}}},
+		{name: "empty word", rules: Rules{BannedWords: []string{""}}},
+		{name: "negative links", rules: Rules{MaxLinks: &negative}},
+		{name: "unknown rating", rules: Rules{MaxRating: &unknown}},
+		{name: "broken pattern", rules: Rules{Patterns: []string{"dark(lord"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compile(tt.rules)
+			if (err == nil) != tt.ok {
+				t.Errorf("compile err = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func judge(t *testing.T, rules Rules, subject string, doc document) []string {
+	t.Helper()
+
+	c, err := compile(rules)
+	if err != nil {
+		t.Fatalf("compile = %v", err)
+	}
+
+	return (&Censor{rules: c}).judge(subject, &doc)
+}
+
+func TestJudgeWords(t *testing.T) {
+	rules := Rules{
+		BannedWords: []string{"Orc"},
+		Patterns:    []string{`(?i)dark\s+lord`},
+	}
+
+	tests := []struct {
+		name string
+		doc  document
+		want []string
+	}{
+		{name: "clean", doc: document{Title: "Elves", Content: "A quiet forest."}},
+		{name: "word", doc: document{Content: "An orc, at the gate!"}, want: []string{`banned word "orc"`}},
+		{name: "any case", doc: document{Title: "ORC"}, want: []string{`banned word "orc"`}},
+		{name: "repeated word", doc: document{Title: "orc", Content: "orc orc"}, want: []string{`banned word "orc"`}},
+		{name: "inside a word", doc: document{Content: "Orcish sorcery in Orcs"}},
+		{name: "phrase", doc: document{Content: "the Dark  Lord rises"}, want: []string{`matches "(?i)dark\\s+lord"`}},
+		{name: "phrase across title and content", doc: document{Title: "Dark", Content: "lord of orc"}, want: []string{
+			`banned word "orc"`,
+			`matches "(?i)dark\\s+lord"`,
+		}},
+		{name: "words of a phrase", doc: document{Content: "dark halls, a lord"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := judge(t, rules, "articles", tt.doc); !slices.Equal(got, tt.want) {
+				t.Errorf("judge = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// mems are judged by their description only.
+	if got := judge(t, rules, "mems", document{Title: "orc", Description: "an orc"}); len(got) != 1 {
+		t.Errorf("judge mem = %q, want one reason", got)
+	}
+}
+
+func TestJudgeLinks(t *testing.T) {
+	none, two := 0, 2
+
+	tests := []struct {
+		name     string
+		maxLinks *int
+		content  string
+		want     []string
+	}{
+		{name: "no limit", content: "http://a https://b http://c"},
+		{name: "none allowed, none there", maxLinks: &none, content: "see the forum"},
+		{name: "none allowed", maxLinks: &none, content: "see https://example.com", want: []string{"1 links, at most 0 allowed"}},
+		{name: "at the limit", maxLinks: &two, content: "http://a and HTTPS://b"},
+		{name: "over the limit", maxLinks: &two, content: "http://a https://b http://c", want: []string{"3 links, at most 2 allowed"}},
+		{name: "not a link", maxLinks: &none, content: "ftp://a xhttp://b http:/c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := judge(t, Rules{MaxLinks: tt.maxLinks}, "articles", document{Content: tt.content})
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("judge = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJudgeTopicsAndRating(t *testing.T) {
+	horror := entity.RatingName(entity.RatingHorror)
+	rules := Rules{Topics: []string{"lore"}, MaxRating: &horror}
+
+	tests := []struct {
+		name    string
+		subject string
+		doc     document
+		want    []string
+	}{
+		{name: "allowed topic", subject: "news", doc: document{Topic: "lore"}},
+		{name: "no topic", subject: "wallpapers", doc: document{}},
+		{name: "other topic", subject: "wallpapers", doc: document{Topic: "war"}, want: []string{`topic "war" is not allowed`}},
+		{name: "article topics", subject: "articles", doc: document{Topics: []string{"lore", "war"}}, want: []string{`topic "war" is not allowed`}},
+		{name: "rating at the limit", subject: "mems", doc: document{Rating: entity.RatingHorror}},
+		{name: "rating over the limit", subject: "mems", doc: document{Rating: entity.RatingGore}, want: []string{"rating gore is above horror"}},
+		{name: "news rating", subject: "news", doc: document{Topic: "lore", Censor: entity.RatingExplicit}, want: []string{"rating explicit is above horror"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := judge(t, rules, tt.subject, tt.doc); !slices.Equal(got, tt.want) {
+				t.Errorf("judge = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package censor
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+)
+
+// Rules is what the local censor checks content against. Empty values turn
+// a rule off.
+type Rules struct {
+	// BannedWords match whole words, case-insensitively. Phrases belong in
+	// Patterns.
+	BannedWords []string `json:"banned_words"`
+	// Patterns are regular expressions matched against the whole text.
+	Patterns []string `json:"patterns"`
+	// Topics, when set, is the only topics content may be filed under.
+	Topics []string `json:"topics"`
+	// MaxLinks caps the number of links in a text, 0 allows none.
+	MaxLinks *int `json:"max_links"`
+	// MaxRating names the highest rating content may carry, New.Censor for
+	// news.
+	MaxRating *string `json:"max_rating"`
+}
+
+var defaultMaxLinks = 5
+
+// DefaultRules keep development usable without a rules file: links are
+// capped and everything else passes.
+var DefaultRules = Rules{
+	MaxLinks: &defaultMaxLinks,
+}
+
+// LoadRules reads rules from a json file, an empty path gives DefaultRules.
+func LoadRules(path string) (Rules, error) {
+	if path == "" {
+		return DefaultRules, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Rules{}, fmt.Errorf("read censor rules: %w", err)
+	}
+
+	var rules Rules
+
+	if err = sonic.Unmarshal(data, &rules); err != nil {
+		return Rules{}, fmt.Errorf("parse censor rules: %w", err)
+	}
+
+	if _, err = compile(rules); err != nil {
+		return Rules{}, err
+	}
+
+	return rules, nil
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\bhttps?://`)
+
+// compiled is Rules prepared for matching.
+type compiled struct {
+	words     map[string]struct{}
+	patterns  []*regexp.Regexp
+	topics    map[string]struct{}
+	maxLinks  *int
+	maxRating *uint8
+}
+
+func compile(rules Rules) (*compiled, error) {
+	c := &compiled{
+		words:    make(map[string]struct{}, len(rules.BannedWords)),
+		maxLinks: rules.MaxLinks,
+	}
+
+	if c.maxLinks != nil && *c.maxLinks < 0 {
+		return nil, fmt.Errorf("censor max_links %d is negative", *c.maxLinks)
+	}
+
+	if rules.MaxRating != nil {
+		rating, ok := entity.ParseRating(*rules.MaxRating)
+		if !ok {
+			return nil, fmt.Errorf("censor max_rating %q is not a rating", *rules.MaxRating)
+		}
+
+		c.maxRating = &rating
+	}
+
+	// text is split into words before the lookup, a phrase would never
+	// match.
+	for _, word := range rules.BannedWords {
+		word = strings.ToLower(word)
+
+		if fields := strings.FieldsFunc(word, notWordRune); len(fields) != 1 || fields[0] != word {
+			return nil, fmt.Errorf("censor banned word %q is not a single word, use a pattern", word)
+		}
+
+		c.words[word] = struct{}{}
+	}
+
+	for _, pattern := range rules.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("compile censor pattern %q: %w", pattern, err)
+		}
+
+		c.patterns = append(c.patterns, re)
+	}
+
+	if len(rules.Topics) > 0 {
+		c.topics = make(map[string]struct{}, len(rules.Topics))
+
+		for _, topic := range rules.Topics {
+			c.topics[topic] = struct{}{}
+		}
+	}
+
+	return c, nil
+}
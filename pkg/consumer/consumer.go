@@ -1,18 +1,46 @@
 package consumer
 
 import (
+	"errors"
+	"time"
+
 	"github.com/bytedance/sonic"
 	"github.com/nats-io/nats.go"
 	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
 	"github.com/osamikoyo/dark-fantasy-land/internal/service"
 	"github.com/osamikoyo/dark-fantasy-land/pkg/logger"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/streams"
 	"go.uber.org/zap"
 )
 
+const (
+	MaxDeliver = 5
+	AckWait    = 30 * time.Second
+)
+
+// Backoff is the delay before the n-th redelivery of a failed verdict, the
+// last step repeats until MaxDeliver runs out.
+var Backoff = []time.Duration{time.Second, 5 * time.Second, 30 * time.Second, 2 * time.Minute}
+
+// verdicts maps every subject the censor answers on to the kind of content
+// its verdicts are about.
+var verdicts = []struct {
+	subject string
+	kind    string
+	durable string
+}{
+	{subject: "uncensored_articles", kind: entity.KindArticle, durable: "verdicts-articles"},
+	{subject: "uncensored_news", kind: entity.KindNews, durable: "verdicts-news"},
+	{subject: "uncensored_mems", kind: entity.KindMem, durable: "verdicts-mems"},
+	{subject: "uncensored_wallpapers", kind: entity.KindWallpaper, durable: "verdicts-wallpapers"},
+}
+
 type Consumer struct {
 	logger  *logger.Logger
 	service *service.Service
 	client  *nats.Conn
+	// images is the pull subscription of upload processing jobs.
+	images *nats.Subscription
 }
 
 func NewConsumer(logger *logger.Logger, service *service.Service, client *nats.Conn) *Consumer {
@@ -23,28 +51,43 @@ func NewConsumer(logger *logger.Logger, service *service.Service, client *nats.C
 	}
 }
 
+// SubscribeAll binds every instance to the same durable consumers, so each
+// verdict is applied once and survives restarts until it is acked.
 func (c *Consumer) SubscribeAll() error {
-	_, err := c.client.Subscribe("uncensored_articles", func(msg *nats.Msg) {
-		var req entity.Request
+	js, err := c.client.JetStream()
+	if err != nil {
+		return err
+	}
 
-		if err := sonic.Unmarshal(msg.Data, &req); err != nil {
-			c.logger.Error("failed unmarshal message body",
-				zap.String("subject", msg.Subject),
+	if err = streams.Ensure(js, streams.Config(streams.Verdicts, streams.VerdictSubjects)); err != nil {
+		c.logger.Error("failed ensure verdicts stream", zap.Error(err))
+
+		return err
+	}
+
+	for _, verdict := range verdicts {
+		_, err = js.QueueSubscribe(verdict.subject, verdict.durable, c.handle(verdict.kind),
+			nats.Durable(verdict.durable),
+			nats.ManualAck(),
+			nats.AckExplicit(),
+			nats.AckWait(AckWait),
+			nats.MaxDeliver(MaxDeliver),
+			nats.DeliverAll(),
+		)
+		if err != nil {
+			c.logger.Error("failed subscribe on censor verdicts",
+				zap.String("subject", verdict.subject),
 				zap.Error(err))
-			return
-		}
 
-		if err := c.service.DeleteArticle(req.Payload["author"], req.Payload["title"]); err != nil {
-			c.logger.Error("failed add article", zap.Error(err))
+			return err
 		}
-	})
-	if err != nil {
-		c.logger.Error("failed subscribe on censored_articles", zap.Error(err))
-
-		return err
 	}
 
-	_, err = c.client.Subscribe("uncensored_mems", func(msg *nats.Msg) {
+	return nil
+}
+
+func (c *Consumer) handle(kind string) nats.MsgHandler {
+	return func(msg *nats.Msg) {
 		var req entity.Request
 
 		if err := sonic.Unmarshal(msg.Data, &req); err != nil {
@@ -52,22 +95,98 @@ func (c *Consumer) SubscribeAll() error {
 				zap.String("subject", msg.Subject),
 				zap.Error(err))
 
+			c.deadLetter(msg, kind, err)
+
 			return
 		}
 
-		if err := c.service.DeleteMem(req.Payload["image_name"], req.Payload["author"]); err != nil {
-			c.logger.Error("failed create mem",
-				zap.Any("mem", req.Payload),
+		err := c.service.ApplyCensorVerdict(kind, &req)
+
+		switch {
+		case err == nil:
+			c.settle(msg, msg.Ack)
+		case errors.Is(err, service.ErrInvalidTransition):
+			// a moderator got there first, the verdict is simply late.
+			c.logger.Info("dropped late censor verdict",
+				zap.String("kind", kind),
+				zap.Any("payload", req.Payload))
+
+			c.settle(msg, msg.Ack)
+		case errors.Is(err, service.ErrInvalidInput), errors.Is(err, service.ErrNotFound):
+			c.logger.Error("refused censor verdict",
+				zap.String("kind", kind),
+				zap.Any("payload", req.Payload),
 				zap.Error(err))
 
-			return
+			c.deadLetter(msg, kind, err)
+		case delivered(msg) >= MaxDeliver:
+			c.logger.Error("failed apply censor verdict, giving up",
+				zap.String("kind", kind),
+				zap.Any("payload", req.Payload),
+				zap.Error(err))
+
+			c.deadLetter(msg, kind, err)
+		default:
+			c.logger.Warn("failed apply censor verdict, will retry",
+				zap.String("kind", kind),
+				zap.Any("payload", req.Payload),
+				zap.Error(err))
+
+			c.settle(msg, func(opts ...nats.AckOpt) error {
+				return msg.NakWithDelay(backoff(msg), opts...)
+			})
 		}
+	}
+}
+
+// deadLetter files msg with the reason it failed and stops its delivery.
+// When even that fails the message is left to redelivery, so it is lost
+// only once MaxDeliver is used up.
+func (c *Consumer) deadLetter(msg *nats.Msg, kind string, cause error) {
+	err := c.service.RecordDeadLetter(&entity.DeadLetter{
+		Subject:  msg.Subject,
+		Kind:     kind,
+		Payload:  string(msg.Data),
+		Error:    cause.Error(),
+		Attempts: delivered(msg),
 	})
 	if err != nil {
-		c.logger.Error("failed subscribe on censored_mems", zap.Error(err))
+		c.logger.Error("failed record dead letter",
+			zap.String("subject", msg.Subject),
+			zap.Error(err))
 
-		return err
+		c.settle(msg, func(opts ...nats.AckOpt) error {
+			return msg.NakWithDelay(backoff(msg), opts...)
+		})
+
+		return
 	}
 
-	return nil
+	c.settle(msg, msg.Term)
+}
+
+func (c *Consumer) settle(msg *nats.Msg, ack func(...nats.AckOpt) error) {
+	if err := ack(); err != nil {
+		c.logger.Error("failed acknowledge message",
+			zap.String("subject", msg.Subject),
+			zap.Error(err))
+	}
+}
+
+func delivered(msg *nats.Msg) uint64 {
+	meta, err := msg.Metadata()
+	if err != nil {
+		return 1
+	}
+
+	return meta.NumDelivered
+}
+
+func backoff(msg *nats.Msg) time.Duration {
+	n := delivered(msg)
+	if n < 1 {
+		return Backoff[0]
+	}
+
+	return Backoff[min(int(n)-1, len(Backoff)-1)]
 }
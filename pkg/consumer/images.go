@@ -0,0 +1,175 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/nats-io/nats.go"
+	"github.com/osamikoyo/dark-fantasy-land/internal/entity"
+	"github.com/osamikoyo/dark-fantasy-land/internal/service"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/streams"
+	"go.uber.org/zap"
+)
+
+const (
+	// ImageJobsDurable is pulled from, legacyImageJobsDurable was the push
+	// consumer before it and is removed on start.
+	ImageJobsDurable       = "image-jobs-pull"
+	legacyImageJobsDurable = "image-jobs"
+
+	// ImageAckWait covers a job being processed, it is only fetched once a
+	// worker is free to take it.
+	ImageAckWait = service.ImageJobTimeout + 30*time.Second
+	// ImageFetchWait is how long one fetch waits for a job to arrive.
+	ImageFetchWait = 30 * time.Second
+	// imageRetryDelay paces fetching again after the broker failed.
+	imageRetryDelay = time.Second
+)
+
+// SubscribeImages binds the pull consumer upload processing jobs are
+// fetched from, FetchImages feeds them to the workers.
+func (c *Consumer) SubscribeImages() error {
+	js, err := c.client.JetStream()
+	if err != nil {
+		return err
+	}
+
+	if err = streams.Ensure(js, streams.Config(streams.Images, streams.ImageSubjects)); err != nil {
+		c.logger.Error("failed ensure images stream", zap.Error(err))
+
+		return err
+	}
+
+	if err = js.DeleteConsumer(streams.Images, legacyImageJobsDurable); err != nil && !errors.Is(err, nats.ErrConsumerNotFound) {
+		c.logger.Warn("failed remove legacy image consumer", zap.Error(err))
+	}
+
+	c.images, err = js.PullSubscribe(service.ImageJobsSubject, ImageJobsDurable,
+		nats.AckExplicit(),
+		nats.AckWait(ImageAckWait),
+		nats.MaxDeliver(MaxDeliver),
+		nats.DeliverAll(),
+	)
+	if err != nil {
+		c.logger.Error("failed subscribe on image jobs", zap.Error(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// FetchImages hands jobs to the service's workers until ctx is done. A job
+// is only fetched once a worker slot is free, so a burst of
+// uploads waits in the stream instead of using up its deliveries.
+func (c *Consumer) FetchImages(ctx context.Context, workers int) {
+	slots := make(chan struct{}, max(1, workers))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case slots <- struct{}{}:
+		}
+
+		msg, ok := c.fetchImage(ctx)
+		if !ok {
+			<-slots
+
+			continue
+		}
+
+		c.handleImage(ctx, msg, func() { <-slots })
+	}
+}
+
+func (c *Consumer) fetchImage(ctx context.Context) (*nats.Msg, bool) {
+	fetchCtx, cancel := context.WithTimeout(ctx, ImageFetchWait)
+	defer cancel()
+
+	msgs, err := c.images.Fetch(1, nats.Context(fetchCtx))
+	if err != nil {
+		if ctx.Err() == nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, nats.ErrTimeout) {
+			c.logger.Warn("failed fetch image job", zap.Error(err))
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(imageRetryDelay):
+			}
+		}
+
+		return nil, false
+	}
+
+	if len(msgs) == 0 {
+		return nil, false
+	}
+
+	return msgs[0], true
+}
+
+// handleImage submits the job of msg, release frees its worker slot once
+// the job is settled.
+func (c *Consumer) handleImage(ctx context.Context, msg *nats.Msg, release func()) {
+	var job entity.ImageJob
+
+	if err := sonic.Unmarshal(msg.Data, &job); err != nil {
+		c.logger.Error("failed unmarshal message body",
+			zap.String("subject", msg.Subject),
+			zap.Error(err))
+
+		c.deadLetter(msg, "", err)
+		release()
+
+		return
+	}
+
+	if err := c.service.SubmitImageJob(ctx, job, func(err error) {
+		c.imageDone(msg, job, err)
+		release()
+	}); err != nil {
+		// shutting down, the job is delivered again to whoever is left.
+		c.settle(msg, msg.Nak)
+		release()
+	}
+}
+
+// imageDone settles msg once a worker finished its job. A job given up on
+// marks the upload failed, so clients polling for it stop waiting.
+func (c *Consumer) imageDone(msg *nats.Msg, job entity.ImageJob, err error) {
+	switch {
+	case err == nil:
+		c.settle(msg, msg.Ack)
+	case errors.Is(err, service.ErrInvalidInput), errors.Is(err, service.ErrNotFound):
+		c.logger.Error("refused image job",
+			zap.String("kind", job.Kind),
+			zap.String("id", job.ID.Hex()),
+			zap.Error(err))
+
+		c.deadLetter(msg, job.Kind, err)
+	case delivered(msg) >= MaxDeliver:
+		c.logger.Error("failed process image, giving up",
+			zap.String("kind", job.Kind),
+			zap.String("id", job.ID.Hex()),
+			zap.Error(err))
+
+		if failErr := c.service.FailImageJob(job, err); failErr != nil {
+			c.logger.Error("failed mark image job failed",
+				zap.String("id", job.ID.Hex()),
+				zap.Error(failErr))
+		}
+
+		c.deadLetter(msg, job.Kind, err)
+	default:
+		c.logger.Warn("failed process image, will retry",
+			zap.String("kind", job.Kind),
+			zap.String("id", job.ID.Hex()),
+			zap.Error(err))
+
+		c.settle(msg, func(opts ...nats.AckOpt) error {
+			return msg.NakWithDelay(backoff(msg), opts...)
+		})
+	}
+}
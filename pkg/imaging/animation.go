@@ -0,0 +1,184 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"io"
+	"time"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+const PosterQuality = 85
+
+var (
+	ErrTooManyFrames = errors.New("animation has too many frames")
+	ErrTooLong       = errors.New("animation runs too long")
+)
+
+// AnimationLimits caps an animated upload, a zero field is not checked.
+// MaxPixels caps the pixels of all frames together, what decoding them
+// allocates. MaxSide is not a limit but the size bigger animations are
+// scaled down to.
+type AnimationLimits struct {
+	MaxFrames   int
+	MaxPixels   int64
+	MaxDuration time.Duration
+	MaxSide     int
+}
+
+// Animation is an animated GIF ready to be stored. Data is the upload
+// itself unless it had to be scaled down, Poster is its first frame as
+// JPEG.
+type Animation struct {
+	Data     []byte
+	Poster   []byte
+	Frames   int
+	Duration time.Duration
+	// LoopCount follows image/gif: 0 loops forever, -1 plays once and n
+	// plays n+1 times.
+	LoopCount int
+}
+
+// frameDelay is how long browsers show a frame, they stretch delays under
+// 20ms to 100ms and so do we when adding up the duration.
+func frameDelay(delay int) time.Duration {
+	if delay < 2 {
+		delay = 10
+	}
+
+	return time.Duration(delay) * 10 * time.Millisecond
+}
+
+// ProcessGIF decodes every frame of the GIF in r, checks it against limits
+// and scales it down frame by frame when it is bigger than MaxSide.
+func ProcessGIF(r io.Reader, limits AnimationLimits) (*Animation, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	// DecodeAll allocates every frame, the limits are checked on the
+	// undecoded blocks first.
+	frames, pixels, ok := countFrames(data)
+	if !ok || frames == 0 {
+		return nil, ErrInvalidImage
+	}
+
+	if limits.MaxFrames > 0 && frames > limits.MaxFrames {
+		return nil, ErrTooManyFrames
+	}
+
+	if limits.MaxPixels > 0 && pixels > limits.MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil || len(g.Image) == 0 {
+		return nil, ErrInvalidImage
+	}
+
+	var duration time.Duration
+	for _, delay := range g.Delay {
+		duration += frameDelay(delay)
+	}
+
+	if limits.MaxDuration > 0 && duration > limits.MaxDuration {
+		return nil, ErrTooLong
+	}
+
+	poster, err := posterFrame(g)
+	if err != nil {
+		return nil, err
+	}
+
+	if side := max(g.Config.Width, g.Config.Height); limits.MaxSide > 0 && side > limits.MaxSide {
+		scaleGIF(g, limits.MaxSide, side)
+
+		var buf bytes.Buffer
+
+		if err = gif.EncodeAll(&buf, g); err != nil {
+			return nil, err
+		}
+
+		data = buf.Bytes()
+	}
+
+	return &Animation{
+		Data:      data,
+		Poster:    poster,
+		Frames:    len(g.Image),
+		Duration:  duration,
+		LoopCount: g.LoopCount,
+	}, nil
+}
+
+// countFrames counts the frames of a GIF and the pixels they hold together
+// without decoding any of them.
+func countFrames(data []byte) (int, int64, bool) {
+	header, ok := gifHeader(data)
+	if !ok {
+		return 0, 0, false
+	}
+
+	var (
+		frames int
+		pixels int64
+	)
+
+	ok = walkGIF(data, header, func(block gifBlock) {
+		if block.introducer == 0x2c {
+			frames++
+			pixels += int64(block.width) * int64(block.height)
+		}
+	})
+
+	return frames, pixels, ok
+}
+
+// posterFrame renders the first frame over the canvas, frames may cover
+// only part of it.
+func posterFrame(g *gif.GIF) ([]byte, error) {
+	canvas := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	if canvas.Bounds().Empty() {
+		canvas = image.NewRGBA(g.Image[0].Bounds())
+	}
+
+	draw.Draw(canvas, canvas.Bounds(), image.Black, image.Point{}, draw.Src)
+	draw.Draw(canvas, g.Image[0].Bounds(), g.Image[0], g.Image[0].Bounds().Min, draw.Over)
+
+	var buf bytes.Buffer
+
+	if err := jpeg.Encode(&buf, canvas, &jpeg.Options{Quality: PosterQuality}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// scaleGIF scales every frame by maxSide/side. Frames keep their palette,
+// offset and disposal, nearest neighbour sampling never mixes in colours
+// the palette lacks or blurs the transparent index into its neighbours.
+func scaleGIF(g *gif.GIF, maxSide, side int) {
+	scale := func(v int) int {
+		return v * maxSide / side
+	}
+
+	for i, frame := range g.Image {
+		r := frame.Bounds()
+
+		rect := image.Rect(scale(r.Min.X), scale(r.Min.Y), max(scale(r.Min.X)+1, scale(r.Max.X)), max(scale(r.Min.Y)+1, scale(r.Max.Y)))
+
+		dst := image.NewPaletted(rect, frame.Palette)
+		xdraw.NearestNeighbor.Scale(dst, rect, frame, r, xdraw.Src, nil)
+
+		g.Image[i] = dst
+	}
+
+	g.Config.Width = max(1, scale(g.Config.Width))
+	g.Config.Height = max(1, scale(g.Config.Height))
+}
@@ -0,0 +1,71 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+func encodeGIF(t *testing.T, frames, side int) []byte {
+	t.Helper()
+
+	palette := color.Palette{color.Black, color.White}
+
+	g := &gif.GIF{}
+	for range frames {
+		g.Image = append(g.Image, image.NewPaletted(image.Rect(0, 0, side, side), palette))
+		g.Delay = append(g.Delay, 10)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestCountFrames(t *testing.T) {
+	data := encodeGIF(t, 3, 20)
+
+	frames, pixels, ok := countFrames(data)
+	if !ok || frames != 3 || pixels != 3*20*20 {
+		t.Fatalf("countFrames = %d, %d, %v, want 3, 1200, true", frames, pixels, ok)
+	}
+
+	for _, cut := range []int{5, 14, len(data) / 2, len(data) - 3} {
+		if _, _, ok := countFrames(data[:cut]); ok {
+			t.Errorf("countFrames of %d of %d bytes accepted a truncated stream", cut, len(data))
+		}
+	}
+}
+
+func TestProcessGIFLimits(t *testing.T) {
+	data := encodeGIF(t, 4, 50)
+
+	tests := []struct {
+		name   string
+		limits AnimationLimits
+		err    error
+	}{
+		{name: "within", limits: AnimationLimits{MaxFrames: 4, MaxPixels: 4 * 50 * 50}},
+		{name: "frames", limits: AnimationLimits{MaxFrames: 3}, err: ErrTooManyFrames},
+		{name: "pixels", limits: AnimationLimits{MaxPixels: 4*50*50 - 1}, err: ErrTooManyPixels},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			animation, err := ProcessGIF(bytes.NewReader(data), tt.limits)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ProcessGIF error = %v, want %v", err, tt.err)
+			}
+
+			if err == nil && animation.Frames != 4 {
+				t.Errorf("Frames = %d, want 4", animation.Frames)
+			}
+		})
+	}
+}
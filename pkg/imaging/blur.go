@@ -0,0 +1,52 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// BlurCells is the width the image is shrunk to, everything finer than
+	// one cell is lost.
+	BlurCells = 16
+	// PreviewWidth caps the width of a blurred preview.
+	PreviewWidth   = 480
+	PreviewQuality = 70
+)
+
+// Blur renders a blurred JPEG preview of the image in r. Shrinking to a few
+// cells and scaling back up bilinearly keeps the colours but nothing
+// recognisable.
+func Blur(r io.Reader) ([]byte, error) {
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+
+	width := min(bounds.Dx(), PreviewWidth)
+	height := max(1, bounds.Dy()*width/max(1, bounds.Dx()))
+
+	cellsY := max(1, bounds.Dy()*BlurCells/max(1, bounds.Dx()))
+
+	small := image.NewRGBA(image.Rect(0, 0, BlurCells, cellsY))
+	draw.ApproxBiLinear.Scale(small, small.Bounds(), src, bounds, draw.Src, nil)
+
+	preview := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.BiLinear.Scale(preview, preview.Bounds(), small, small.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+
+	if err = jpeg.Encode(&buf, preview, &jpeg.Options{Quality: PreviewQuality}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
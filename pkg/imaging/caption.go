@@ -0,0 +1,210 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Where a caption hangs from its Y.
+const (
+	AnchorCenter = iota
+	AnchorTop
+	AnchorBottom
+)
+
+const (
+	CaptionQuality = 90
+	// MinCaptionSize is the smallest font size auto-sizing goes down to,
+	// text that still does not fit is drawn over the bounds.
+	MinCaptionSize = 10
+	// MaxCaptionHeight caps the share of the image height one caption
+	// takes.
+	MaxCaptionHeight = 0.3
+	// CaptionMargin is kept free on both sides of a top or bottom caption.
+	CaptionMargin = 0.04
+)
+
+// Caption is text drawn onto an image. X, Y and Width are fractions of the
+// image size, X is the horizontal centre and Width caps how wide the text
+// may run before it wraps.
+type Caption struct {
+	Text   string
+	X      float64
+	Y      float64
+	Width  float64
+	Anchor int
+}
+
+func TopCaption(text string) Caption {
+	return Caption{Text: text, X: 0.5, Y: CaptionMargin, Width: 1 - 2*CaptionMargin, Anchor: AnchorTop}
+}
+
+func BottomCaption(text string) Caption {
+	return Caption{Text: text, X: 0.5, Y: 1 - CaptionMargin, Width: 1 - 2*CaptionMargin, Anchor: AnchorBottom}
+}
+
+var (
+	captionFont     *opentype.Font
+	captionFontErr  error
+	captionFontOnce sync.Once
+)
+
+// captionFace returns the embedded caption font at size, it is parsed once.
+func captionFace(size float64) (font.Face, error) {
+	captionFontOnce.Do(func() {
+		captionFont, captionFontErr = opentype.Parse(gobold.TTF)
+	})
+
+	if captionFontErr != nil {
+		return nil, captionFontErr
+	}
+
+	return opentype.NewFace(captionFont, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+}
+
+// DrawCaptions draws captions onto a copy of src in white with a black
+// outline, the classic meme look, and encodes the result as JPEG.
+func DrawCaptions(src image.Image, captions []Caption) ([]byte, error) {
+	bounds := src.Bounds()
+
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+
+	for _, caption := range captions {
+		if err := drawCaption(dst, caption); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: CaptionQuality}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func drawCaption(dst *image.RGBA, caption Caption) error {
+	width, height := dst.Bounds().Dx(), dst.Bounds().Dy()
+
+	maxWidth := fixed.I(max(1, int(caption.Width*float64(width))))
+	maxHeight := fixed.I(max(1, int(MaxCaptionHeight*float64(height))))
+
+	face, lines, err := fit(caption.Text, float64(height)/7, maxWidth, maxHeight)
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+
+	metrics := face.Metrics()
+	block := metrics.Height.Mul(fixed.I(len(lines)))
+
+	top := fixed.I(int(caption.Y * float64(height)))
+	switch caption.Anchor {
+	case AnchorCenter:
+		top -= block / 2
+	case AnchorBottom:
+		top -= block
+	}
+
+	outline := max(1, int(metrics.Height.Round()/14))
+	centre := fixed.I(int(caption.X * float64(width)))
+
+	for i, line := range lines {
+		dot := fixed.Point26_6{
+			X: centre - font.MeasureString(face, line)/2,
+			Y: top + metrics.Height.Mul(fixed.I(i)) + metrics.Ascent,
+		}
+
+		for dx := -outline; dx <= outline; dx++ {
+			for dy := -outline; dy <= outline; dy++ {
+				if dx*dx+dy*dy > outline*outline {
+					continue
+				}
+
+				drawLine(dst, face, line, image.Black, dot.Add(fixed.P(dx, dy)))
+			}
+		}
+
+		drawLine(dst, face, line, image.White, dot)
+	}
+
+	return nil
+}
+
+func drawLine(dst *image.RGBA, face font.Face, line string, c color.Color, dot fixed.Point26_6) {
+	d := font.Drawer{Dst: dst, Src: image.NewUniform(c), Face: face, Dot: dot}
+	d.DrawString(line)
+}
+
+// fit shrinks the font from size until text wraps into maxWidth and
+// maxHeight, at MinCaptionSize it gives up and returns what it has.
+func fit(text string, size float64, maxWidth, maxHeight fixed.Int26_6) (font.Face, []string, error) {
+	for {
+		face, err := captionFace(size)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		lines, ok := wrap(face, text, maxWidth)
+		if ok && face.Metrics().Height.Mul(fixed.I(len(lines))) <= maxHeight || size <= MinCaptionSize {
+			return face, lines, nil
+		}
+
+		face.Close()
+		size = max(MinCaptionSize, size*0.9)
+	}
+}
+
+// wrap breaks text into lines no wider than maxWidth at spaces, ok is false
+// when a single word does not fit and got a line of its own anyway.
+func wrap(face font.Face, text string, maxWidth fixed.Int26_6) ([]string, bool) {
+	var lines []string
+
+	ok := true
+
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+
+		for _, word := range strings.Fields(paragraph) {
+			if font.MeasureString(face, word) > maxWidth {
+				ok = false
+			}
+
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+
+			if font.MeasureString(face, candidate) <= maxWidth {
+				line = candidate
+
+				continue
+			}
+
+			if line != "" {
+				lines = append(lines, line)
+			}
+			line = word
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, ok
+}
@@ -0,0 +1,101 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// gifBlock is one extension or image of a GIF stream.
+type gifBlock struct {
+	// introducer is 0x21 for an extension and 0x2c for an image, label
+	// tells extensions apart.
+	introducer byte
+	label      byte
+	// raw is the whole block, body the sub-blocks of an extension.
+	raw  []byte
+	body []byte
+	// width and height are those of an image.
+	width  int
+	height int
+}
+
+// gifHeader is the length of the header, the screen descriptor and the
+// global color table of a GIF.
+func gifHeader(data []byte) (int, bool) {
+	if len(data) < 13 || !bytes.HasPrefix(data, []byte("GIF")) {
+		return 0, false
+	}
+
+	n := 13
+	if data[10]&0x80 != 0 {
+		n += 3 << (data[10]&0x07 + 1)
+	}
+
+	return n, n <= len(data)
+}
+
+// walkGIF calls visit with every block from offset from up to the trailer,
+// nothing is decoded. It reports whether the stream was well formed, a
+// missing trailer is accepted like image/gif does.
+func walkGIF(data []byte, from int, visit func(gifBlock)) bool {
+	// subBlocks returns where the sub-blocks starting at j end.
+	subBlocks := func(j int) (int, bool) {
+		for j < len(data) {
+			size := int(data[j])
+			j += 1 + size
+
+			if size == 0 {
+				return j, j <= len(data)
+			}
+		}
+
+		return 0, false
+	}
+
+	i := from
+	for i < len(data) {
+		switch data[i] {
+		case 0x3b:
+			return true
+		case 0x21:
+			if i+2 > len(data) {
+				return false
+			}
+
+			end, ok := subBlocks(i + 2)
+			if !ok {
+				return false
+			}
+
+			visit(gifBlock{introducer: 0x21, label: data[i+1], raw: data[i:end], body: data[i+2 : end]})
+			i = end
+		case 0x2c:
+			j := i + 10
+			if j > len(data) {
+				return false
+			}
+
+			if flags := data[i+9]; flags&0x80 != 0 {
+				j += 3 << (flags&0x07 + 1)
+			}
+
+			// the byte after the color table is the LZW code size.
+			end, ok := subBlocks(j + 1)
+			if !ok {
+				return false
+			}
+
+			visit(gifBlock{
+				introducer: 0x2c,
+				raw:        data[i:end],
+				width:      int(binary.LittleEndian.Uint16(data[i+5:])),
+				height:     int(binary.LittleEndian.Uint16(data[i+7:])),
+			})
+			i = end
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,437 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/image/draw"
+)
+
+// Metadata fields a deployment may keep, see Metadata.Keep.
+const (
+	MetaArtist    = "artist"
+	MetaCopyright = "copyright"
+)
+
+const (
+	// OrientedQuality is what a JPEG is encoded at again once its pixels
+	// had to be turned.
+	OrientedQuality = 92
+
+	// MaxMetaLength caps a kept text field, in runes.
+	MaxMetaLength = 256
+)
+
+const (
+	tagOrientation = 0x0112
+	tagArtist      = 0x013b
+	tagCopyright   = 0x8298
+)
+
+// Metadata is what an upload told about itself before it was stripped.
+type Metadata struct {
+	// Orientation is the EXIF orientation, 1 to 8, 0 when there was none.
+	Orientation int
+	Artist      string
+	Copyright   string
+}
+
+// Keep drops every field not named in fields.
+func (m Metadata) Keep(fields []string) Metadata {
+	kept := Metadata{Orientation: m.Orientation}
+
+	for _, field := range fields {
+		switch field {
+		case MetaArtist:
+			kept.Artist = m.Artist
+		case MetaCopyright:
+			kept.Copyright = m.Copyright
+		}
+	}
+
+	return kept
+}
+
+// Clean is an upload without its metadata and with its orientation
+// applied to the pixels.
+type Clean struct {
+	Data []byte
+	Info Info
+	Meta Metadata
+}
+
+// Strip removes the metadata of data, an image described by info, and
+// returns what it said. Segments a decoder needs, like color profiles, are
+// kept. An image that is not upright is turned and encoded again, any
+// other is only cut, so its pixels are left exactly as they were.
+func Strip(data []byte, info Info) (Clean, error) {
+	var (
+		out  []byte
+		exif []byte
+		ok   bool
+	)
+
+	switch info.Format {
+	case "jpeg":
+		out, exif, ok = stripJPEG(data)
+	case "png":
+		out, exif, ok = stripPNG(data)
+	case "gif":
+		out, ok = stripGIF(data)
+	default:
+		return Clean{}, ErrUnsupportedFormat
+	}
+
+	if !ok {
+		return Clean{}, ErrInvalidImage
+	}
+
+	clean := Clean{Data: out, Info: info, Meta: readExif(exif)}
+
+	if clean.Meta.Orientation < 2 || info.Format == "gif" {
+		return clean, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(out))
+	if err != nil {
+		return Clean{}, ErrInvalidImage
+	}
+
+	oriented := Orient(img, clean.Meta.Orientation)
+
+	var buf bytes.Buffer
+	if info.Format == "png" {
+		err = png.Encode(&buf, oriented)
+	} else {
+		err = jpeg.Encode(&buf, oriented, &jpeg.Options{Quality: OrientedQuality})
+	}
+	if err != nil {
+		return Clean{}, ErrInvalidImage
+	}
+
+	clean.Data = buf.Bytes()
+	clean.Info.Width, clean.Info.Height = oriented.Rect.Dx(), oriented.Rect.Dy()
+
+	return clean, nil
+}
+
+// keptJPEG tells the application segments a decoder may need apart from
+// those only describing the picture.
+func keptJPEG(marker byte, payload []byte) bool {
+	switch marker {
+	case 0xe0:
+		return bytes.HasPrefix(payload, []byte("JFIF\x00"))
+	case 0xe2:
+		return bytes.HasPrefix(payload, []byte("ICC_PROFILE\x00"))
+	case 0xee:
+		return bytes.HasPrefix(payload, []byte("Adobe"))
+	default:
+		return false
+	}
+}
+
+// stripJPEG copies the segments of a JPEG worth keeping and returns the
+// TIFF data of its EXIF segment. Everything past the end of image marker,
+// where phones append depth maps and previews, is dropped.
+func stripJPEG(data []byte) ([]byte, []byte, bool) {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil, nil, false
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xff, 0xd8)
+
+	var exif []byte
+
+	i := 2
+	for i+1 < len(data) {
+		if data[i] != 0xff {
+			return nil, nil, false
+		}
+
+		marker := data[i+1]
+
+		switch {
+		case marker == 0xff:
+			// fill byte before a marker.
+			i++
+
+			continue
+		case marker == 0xd9:
+			return append(out, 0xff, 0xd9), exif, true
+		case marker >= 0xd0 && marker <= 0xd7 || marker == 0x01:
+			out = append(out, 0xff, marker)
+			i += 2
+
+			continue
+		}
+
+		if i+4 > len(data) {
+			return nil, nil, false
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, nil, false
+		}
+
+		payload := data[i+4 : end]
+
+		switch {
+		case marker == 0xe1 && bytes.HasPrefix(payload, []byte("Exif\x00\x00")):
+			if exif == nil {
+				exif = payload[6:]
+			}
+		case marker >= 0xe0 && marker <= 0xef || marker == 0xfe:
+			if keptJPEG(marker, payload) {
+				out = append(out, data[i:end]...)
+			}
+		default:
+			out = append(out, data[i:end]...)
+		}
+
+		i = end
+
+		if marker != 0xda {
+			continue
+		}
+
+		// entropy coded data runs up to the next marker that is neither
+		// a stuffed zero nor a restart.
+		start := i
+		for i+1 < len(data) {
+			if data[i] == 0xff && data[i+1] != 0 && (data[i+1] < 0xd0 || data[i+1] > 0xd7) {
+				break
+			}
+			i++
+		}
+
+		out = append(out, data[start:i]...)
+	}
+
+	// a JPEG cut short still decodes, it just ends without its marker.
+	return append(out, 0xff, 0xd9), exif, true
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// strippedPNG are the chunks of a PNG that only describe the picture.
+var strippedPNG = map[string]bool{
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"eXIf": true,
+	"tIME": true,
+}
+
+// stripPNG copies the chunks of a PNG worth keeping up to IEND and returns
+// the TIFF data of its eXIf chunk.
+func stripPNG(data []byte) ([]byte, []byte, bool) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, nil, false
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+
+	var exif []byte
+
+	i := len(pngSignature)
+	for i+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + length
+		if end > len(data) {
+			return nil, nil, false
+		}
+
+		kind := string(data[i+4 : i+8])
+
+		if kind == "eXIf" && exif == nil {
+			exif = data[i+8 : i+8+length]
+		}
+
+		if !strippedPNG[kind] {
+			out = append(out, data[i:end]...)
+		}
+
+		if kind == "IEND" {
+			return out, exif, true
+		}
+
+		i = end
+	}
+
+	return nil, nil, false
+}
+
+// stripGIF drops the comments of a GIF and every application extension
+// but the one carrying the loop count, XMP packets travel in those.
+func stripGIF(data []byte) ([]byte, bool) {
+	header, ok := gifHeader(data)
+	if !ok {
+		return nil, false
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:header]...)
+
+	if !walkGIF(data, header, func(block gifBlock) {
+		if block.introducer == 0x2c || keptGIF(block.label, block.body) {
+			out = append(out, block.raw...)
+		}
+	}) {
+		return nil, false
+	}
+
+	return append(out, 0x3b), true
+}
+
+func keptGIF(label byte, blocks []byte) bool {
+	switch label {
+	case 0xfe:
+		return false
+	case 0xff:
+		return len(blocks) >= 12 && blocks[0] == 11 &&
+			(string(blocks[1:12]) == "NETSCAPE2.0" || string(blocks[1:12]) == "ANIMEXTS1.0")
+	default:
+		return true
+	}
+}
+
+// readExif picks the fields we look at out of the first IFD of TIFF data,
+// anything malformed is skipped.
+func readExif(tiff []byte) Metadata {
+	var meta Metadata
+
+	if len(tiff) < 8 {
+		return meta
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return meta
+	}
+
+	if order.Uint16(tiff[2:]) != 42 {
+		return meta
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return meta
+	}
+
+	count := int(order.Uint16(tiff[ifd:]))
+
+	for n := range count {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			break
+		}
+
+		tag, kind := order.Uint16(tiff[entry:]), order.Uint16(tiff[entry+2:])
+		size := int(order.Uint32(tiff[entry+4:]))
+
+		switch tag {
+		case tagOrientation:
+			// a SHORT stored in the first bytes of the value field.
+			if v := int(order.Uint16(tiff[entry+8:])); kind == 3 && v >= 1 && v <= 8 {
+				meta.Orientation = v
+			}
+		case tagArtist, tagCopyright:
+			// an ASCII value longer than four bytes lives at an offset.
+			if kind != 2 || size <= 0 {
+				continue
+			}
+
+			value := tiff[entry+8 : entry+12]
+			if size > 4 {
+				offset := int(order.Uint32(tiff[entry+8:]))
+				if offset < 0 || offset+size > len(tiff) {
+					continue
+				}
+
+				value = tiff[offset : offset+size]
+			} else {
+				value = value[:size]
+			}
+
+			text := metaText(value)
+			if tag == tagArtist {
+				meta.Artist = text
+			} else {
+				meta.Copyright = text
+			}
+		}
+	}
+
+	return meta
+}
+
+func metaText(value []byte) string {
+	text := strings.TrimSpace(strings.ToValidUTF8(string(bytes.TrimRight(value, "\x00")), ""))
+
+	if utf8.RuneCountInString(text) > MaxMetaLength {
+		text = string([]rune(text)[:MaxMetaLength])
+	}
+
+	return text
+}
+
+// Orient turns img upright according to an EXIF orientation.
+func Orient(img image.Image, orientation int) *image.NRGBA {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	src := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(src, src.Rect, img, bounds.Min, draw.Src)
+
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+
+			copy(dst.Pix[dst.PixOffset(x, y):][:4], src.Pix[src.PixOffset(sx, sy):][:4])
+		}
+	}
+
+	return dst
+}
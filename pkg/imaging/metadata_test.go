@@ -0,0 +1,460 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+type tiffEntry struct {
+	tag   uint16
+	kind  uint16
+	count uint32
+	value []byte
+}
+
+// tiffData lays entries out as the first IFD of TIFF data, values longer
+// than four bytes go after it.
+func tiffData(order binary.AppendByteOrder, entries ...tiffEntry) []byte {
+	data := []byte("II")
+	if order == binary.BigEndian {
+		data = []byte("MM")
+	}
+
+	data = order.AppendUint16(data, 42)
+	data = order.AppendUint32(data, 8)
+	data = order.AppendUint16(data, uint16(len(entries)))
+
+	extra := 8 + 2 + len(entries)*12 + 4
+
+	var tail []byte
+	for _, e := range entries {
+		data = order.AppendUint16(data, e.tag)
+		data = order.AppendUint16(data, e.kind)
+		data = order.AppendUint32(data, e.count)
+
+		if len(e.value) <= 4 {
+			data = append(data, append(e.value, make([]byte, 4-len(e.value))...)...)
+			continue
+		}
+
+		data = order.AppendUint32(data, uint32(extra+len(tail)))
+		tail = append(tail, e.value...)
+	}
+
+	data = order.AppendUint32(data, 0)
+
+	return append(data, tail...)
+}
+
+func orientationEntry(order binary.AppendByteOrder, orientation int) tiffEntry {
+	return tiffEntry{tag: tagOrientation, kind: 3, count: 1, value: order.AppendUint16(nil, uint16(orientation))}
+}
+
+func asciiEntry(tag uint16, text string) tiffEntry {
+	return tiffEntry{tag: tag, kind: 2, count: uint32(len(text) + 1), value: append([]byte(text), 0)}
+}
+
+func jpegSegment(marker byte, payload []byte) []byte {
+	segment := []byte{0xff, marker}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+
+	return append(segment, payload...)
+}
+
+func exifSegment(tiff []byte) []byte {
+	return jpegSegment(0xe1, append([]byte("Exif\x00\x00"), tiff...))
+}
+
+func pngChunk(kind string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, kind...)
+	chunk = append(chunk, data...)
+
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+// testImage has a distinct color at every pixel, so where each one ends up
+// can be told.
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 255 / max(1, w-1)), G: uint8(y * 255 / max(1, h-1)), B: uint8(x ^ y), A: 0xff})
+		}
+	}
+
+	return img
+}
+
+// encodeJPEG encodes img and puts segments right after the start of image
+// marker.
+func encodeJPEG(t testing.TB, img image.Image, segments ...[]byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+
+	data := append([]byte{}, buf.Bytes()[:2]...)
+	for _, segment := range segments {
+		data = append(data, segment...)
+	}
+
+	return append(data, buf.Bytes()[2:]...)
+}
+
+// encodePNG encodes img and puts chunks right after IHDR.
+func encodePNG(t testing.TB, img image.Image, chunks ...[]byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	header := len(pngSignature) + 25
+
+	data := append([]byte{}, buf.Bytes()[:header]...)
+	for _, chunk := range chunks {
+		data = append(data, chunk...)
+	}
+
+	return append(data, buf.Bytes()[header:]...)
+}
+
+func TestStripJPEG(t *testing.T) {
+	tiff := tiffData(binary.BigEndian,
+		orientationEntry(binary.BigEndian, 1),
+		asciiEntry(tagArtist, "Jane Doe"),
+		asciiEntry(tagCopyright, "(c) 2026"))
+
+	data := encodeJPEG(t, testImage(16, 8),
+		exifSegment(tiff),
+		jpegSegment(0xe1, []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta/>")),
+		jpegSegment(0xfe, []byte("a comment")),
+		jpegSegment(0xe2, []byte("ICC_PROFILE\x00\x01\x01")))
+	data = append(data, "trailing preview"...)
+
+	clean, err := Strip(data, Info{Format: "jpeg", Width: 16, Height: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Metadata{Orientation: 1, Artist: "Jane Doe", Copyright: "(c) 2026"}
+	if clean.Meta != want {
+		t.Errorf("Meta = %+v, want %+v", clean.Meta, want)
+	}
+
+	for _, gone := range []string{"Exif", "xmpmeta", "a comment", "trailing preview"} {
+		if bytes.Contains(clean.Data, []byte(gone)) {
+			t.Errorf("stripped jpeg still holds %q", gone)
+		}
+	}
+
+	if !bytes.Contains(clean.Data, []byte("ICC_PROFILE")) {
+		t.Error("stripped jpeg lost its color profile")
+	}
+
+	if _, err = jpeg.Decode(bytes.NewReader(clean.Data)); err != nil {
+		t.Errorf("stripped jpeg does not decode: %v", err)
+	}
+}
+
+func TestStripPNG(t *testing.T) {
+	tiff := tiffData(binary.LittleEndian, asciiEntry(tagArtist, "Jane"))
+
+	data := encodePNG(t, testImage(4, 4),
+		pngChunk("eXIf", tiff),
+		pngChunk("tEXt", []byte("Comment\x00hello")))
+
+	clean, err := Strip(data, Info{Format: "png", Width: 4, Height: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if clean.Meta.Artist != "Jane" {
+		t.Errorf("Artist = %q, want Jane", clean.Meta.Artist)
+	}
+
+	for _, gone := range []string{"eXIf", "tEXt"} {
+		if bytes.Contains(clean.Data, []byte(gone)) {
+			t.Errorf("stripped png still holds %s", gone)
+		}
+	}
+
+	// no orientation, the pixels must not have been touched.
+	if _, err = png.Decode(bytes.NewReader(clean.Data)); err != nil {
+		t.Errorf("stripped png does not decode: %v", err)
+	}
+}
+
+func TestStripMalformed(t *testing.T) {
+	jpg := encodeJPEG(t, testImage(8, 8), exifSegment(tiffData(binary.BigEndian, asciiEntry(tagArtist, "Jane"))))
+	pngData := encodePNG(t, testImage(8, 8), pngChunk("tEXt", []byte("Comment\x00hello")))
+	gifData := encodeGIF(t, 2, 8)
+
+	// oversized sets the length of the segment or chunk found at offset.
+	oversized := func(data []byte, offset int, length []byte) []byte {
+		out := append([]byte{}, data...)
+		copy(out[offset:], length)
+
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		data   []byte
+	}{
+		{name: "jpeg too short", format: "jpeg", data: jpg[:3]},
+		{name: "jpeg no start marker", format: "jpeg", data: jpg[2:]},
+		{name: "jpeg cut in segment header", format: "jpeg", data: jpg[:5]},
+		{name: "jpeg cut in segment", format: "jpeg", data: jpg[:20]},
+		{name: "jpeg segment past end", format: "jpeg", data: oversized(jpg, 4, []byte{0xff, 0xff})},
+		{name: "jpeg segment under its length field", format: "jpeg", data: oversized(jpg, 4, []byte{0x00, 0x01})},
+		{name: "png bad signature", format: "png", data: pngData[1:]},
+		{name: "png cut in chunk", format: "png", data: pngData[:len(pngSignature)+10]},
+		{name: "png without IEND", format: "png", data: pngData[:len(pngData)-12]},
+		{name: "png chunk past end", format: "png", data: oversized(pngData, len(pngSignature), []byte{0x7f, 0xff, 0xff, 0xff})},
+		{name: "gif cut in header", format: "gif", data: gifData[:8]},
+		{name: "gif cut in frame", format: "gif", data: gifData[:len(gifData)/2]},
+		{name: "gif without block terminator", format: "gif", data: gifData[:len(gifData)-2]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Strip(tt.data, Info{Format: tt.format}); !errors.Is(err, ErrInvalidImage) {
+				t.Errorf("Strip error = %v, want %v", err, ErrInvalidImage)
+			}
+		})
+	}
+
+	// image/gif takes a stream without its trailer, so does Strip.
+	if clean, err := Strip(gifData[:len(gifData)-1], Info{Format: "gif"}); err != nil || !bytes.Equal(clean.Data, gifData) {
+		t.Errorf("Strip of gif without trailer = %v, want the trailer back", err)
+	}
+
+	if _, err := Strip(jpg, Info{Format: "webp"}); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Strip of webp error = %v, want %v", err, ErrUnsupportedFormat)
+	}
+}
+
+func TestStripGIF(t *testing.T) {
+	g := &gif.GIF{
+		Image: []*image.Paletted{image.NewPaletted(image.Rect(0, 0, 4, 4), color.Palette{color.Black, color.White})},
+		Delay: []int{10},
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+
+	// the go encoder writes no global color table, blocks start at 13.
+	comment := append([]byte{0x21, 0xfe, 5}, "hello\x00"...)
+	data := append(append(append([]byte{}, buf.Bytes()[:13]...), comment...), buf.Bytes()[13:]...)
+
+	clean, err := Strip(data, Info{Format: "gif"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(clean.Data, []byte("hello")) {
+		t.Error("stripped gif still holds its comment")
+	}
+
+	if _, err = gif.DecodeAll(bytes.NewReader(clean.Data)); err != nil {
+		t.Errorf("stripped gif does not decode: %v", err)
+	}
+}
+
+func TestReadExif(t *testing.T) {
+	le, be := binary.LittleEndian, binary.BigEndian
+
+	valid := tiffData(le, orientationEntry(le, 6), asciiEntry(tagArtist, "Jane Doe"))
+
+	// pointAt moves the value offset of the entry at index.
+	pointAt := func(tiff []byte, index int, offset uint32) []byte {
+		out := append([]byte{}, tiff...)
+		le.PutUint32(out[10+index*12+8:], offset)
+
+		return out
+	}
+
+	tests := []struct {
+		name string
+		tiff []byte
+		want Metadata
+	}{
+		{name: "little endian", tiff: valid, want: Metadata{Orientation: 6, Artist: "Jane Doe"}},
+		{name: "big endian", tiff: tiffData(be, orientationEntry(be, 3), asciiEntry(tagCopyright, "me")), want: Metadata{Orientation: 3, Copyright: "me"}},
+		{name: "short value inline", tiff: tiffData(le, asciiEntry(tagArtist, "abc")), want: Metadata{Artist: "abc"}},
+		{name: "empty", tiff: nil},
+		{name: "bad byte order", tiff: append([]byte("XX"), valid[2:]...)},
+		{name: "bad magic", tiff: append([]byte("II\x2b\x00"), valid[4:]...)},
+		{name: "ifd past end", tiff: append(append([]byte{}, valid[:4]...), 0xff, 0xff, 0xff, 0x7f)},
+		{name: "ifd in header", tiff: append(append([]byte{}, valid[:4]...), 4, 0, 0, 0)},
+		{name: "cut in entries", tiff: valid[:10+12+6], want: Metadata{Orientation: 6}},
+		{name: "value past end", tiff: pointAt(valid, 1, 0xfffffff0), want: Metadata{Orientation: 6}},
+		{name: "value offset overflows", tiff: pointAt(valid, 1, 0xffffffff), want: Metadata{Orientation: 6}},
+		{name: "orientation out of range", tiff: tiffData(le, orientationEntry(le, 9))},
+		{name: "orientation not a short", tiff: tiffData(le, tiffEntry{tag: tagOrientation, kind: 4, count: 1, value: le.AppendUint32(nil, 6)})},
+		{name: "oversized count", tiff: func() []byte {
+			out := append([]byte{}, valid...)
+			le.PutUint16(out[8:], 0xffff)
+
+			return out
+		}(), want: Metadata{Orientation: 6, Artist: "Jane Doe"}},
+		{name: "oversized ascii count", tiff: tiffData(le, tiffEntry{tag: tagArtist, kind: 2, count: 0xffffffff, value: []byte("Jane Doe")})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readExif(tt.tiff); got != tt.want {
+				t.Errorf("readExif = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// orientedAt is where EXIF orientation puts the source pixel x, y of a w
+// by h image once it is upright.
+func orientedAt(orientation, x, y, w, h int) (int, int) {
+	switch orientation {
+	case 2: // mirrored
+		return w - 1 - x, y
+	case 3: // upside down
+		return w - 1 - x, h - 1 - y
+	case 4: // flipped
+		return x, h - 1 - y
+	case 5: // transposed
+		return y, x
+	case 6: // needs a quarter turn clockwise
+		return h - 1 - y, x
+	case 7: // transversed
+		return h - 1 - y, w - 1 - x
+	case 8: // needs a quarter turn counterclockwise
+		return y, w - 1 - x
+	default:
+		return x, y
+	}
+}
+
+func TestOrient(t *testing.T) {
+	const w, h = 5, 3
+
+	src := testImage(w, h)
+
+	for orientation := 1; orientation <= 8; orientation++ {
+		dst := Orient(src, orientation)
+
+		dw, dh := w, h
+		if orientation >= 5 {
+			dw, dh = h, w
+		}
+
+		if dst.Rect.Dx() != dw || dst.Rect.Dy() != dh {
+			t.Errorf("orientation %d: size %v, want %dx%d", orientation, dst.Rect.Size(), dw, dh)
+			continue
+		}
+
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				dx, dy := orientedAt(orientation, x, y, w, h)
+				if got, want := dst.NRGBAAt(dx, dy), src.NRGBAAt(x, y); got != want {
+					t.Errorf("orientation %d: pixel %d,%d moved to %d,%d is %v, want %v", orientation, x, y, dx, dy, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestStripOrients(t *testing.T) {
+	const w, h = 6, 4
+
+	src := testImage(w, h)
+
+	for orientation := 1; orientation <= 8; orientation++ {
+		data := encodePNG(t, src, pngChunk("eXIf", tiffData(binary.BigEndian, orientationEntry(binary.BigEndian, orientation))))
+
+		clean, err := Strip(data, Info{Format: "png", Width: w, Height: h})
+		if err != nil {
+			t.Fatalf("orientation %d: %v", orientation, err)
+		}
+
+		if clean.Meta.Orientation != orientation {
+			t.Errorf("orientation %d: read %d", orientation, clean.Meta.Orientation)
+		}
+
+		img, err := png.Decode(bytes.NewReader(clean.Data))
+		if err != nil {
+			t.Fatalf("orientation %d: %v", orientation, err)
+		}
+
+		if size := img.Bounds().Size(); size.X != clean.Info.Width || size.Y != clean.Info.Height {
+			t.Errorf("orientation %d: Info says %dx%d, image is %v", orientation, clean.Info.Width, clean.Info.Height, size)
+		}
+
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				dx, dy := orientedAt(orientation, x, y, w, h)
+				if got, want := color.NRGBAModel.Convert(img.At(dx, dy)), src.NRGBAAt(x, y); got != want {
+					t.Fatalf("orientation %d: pixel %d,%d moved to %d,%d is %v, want %v", orientation, x, y, dx, dy, got, want)
+				}
+			}
+		}
+	}
+}
+
+func FuzzStrip(f *testing.F) {
+	tiff := tiffData(binary.LittleEndian,
+		orientationEntry(binary.LittleEndian, 6),
+		asciiEntry(tagArtist, "Jane Doe"),
+		asciiEntry(tagCopyright, "(c) 2026"))
+
+	f.Add(encodeJPEG(f, testImage(8, 8), exifSegment(tiff), jpegSegment(0xfe, []byte("comment"))))
+	f.Add(encodePNG(f, testImage(8, 8), pngChunk("eXIf", tiff), pngChunk("tEXt", []byte("a\x00b"))))
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, &gif.GIF{
+		Image: []*image.Paletted{image.NewPaletted(image.Rect(0, 0, 4, 4), color.Palette{color.Black, color.White})},
+		Delay: []int{10},
+	}); err != nil {
+		f.Fatal(err)
+	}
+	f.Add(buf.Bytes())
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// uploads are validated before they are stripped, huge pictures
+		// never get here.
+		if config, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil && config.Width*config.Height > 1<<16 {
+			t.Skip()
+		}
+
+		for _, format := range []string{"jpeg", "png", "gif"} {
+			clean, err := Strip(data, Info{Format: format})
+			if err != nil {
+				continue
+			}
+
+			// what was stripped once has nothing left to strip.
+			again, err := Strip(clean.Data, clean.Info)
+			if err != nil {
+				t.Fatalf("%s: stripping the stripped image failed: %v", format, err)
+			}
+
+			if again.Meta != (Metadata{}) {
+				t.Fatalf("%s: stripped image still carries %+v", format, again.Meta)
+			}
+		}
+	})
+}
@@ -0,0 +1,319 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// PaletteSize is how many colors k-means splits an image into.
+	PaletteSize = 5
+
+	// paletteSample is the side an image is shrunk to before clustering,
+	// the palette of a 4k image does not need every one of its pixels.
+	paletteSample = 64
+
+	paletteRounds = 20
+
+	// ColorBinSize is the side of the Lab cube a color is filed under, two
+	// colors closer than it always share a bin with their neighbours, see
+	// NearBins.
+	ColorBinSize = 20.0
+)
+
+// Lab is a color in CIE L*a*b* under the D65 white point, distances in it
+// follow what the eye sees.
+type Lab struct {
+	L, A, B float64
+}
+
+// Swatch is one color of a palette and the share of the image it covers.
+type Swatch struct {
+	Color  color.NRGBA
+	Lab    Lab
+	Weight float64
+}
+
+// Hex formats the swatch color as #rrggbb.
+func (s Swatch) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", s.Color.R, s.Color.G, s.Color.B)
+}
+
+// ParseHex reads #rrggbb, the hash is optional.
+func ParseHex(value string) (color.NRGBA, bool) {
+	value = strings.TrimPrefix(value, "#")
+	if len(value) != 6 {
+		return color.NRGBA{}, false
+	}
+
+	n, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, false
+	}
+
+	return color.NRGBA{R: uint8(n >> 16), G: uint8(n >> 8), B: uint8(n), A: 0xff}, true
+}
+
+// LabOf converts an sRGB color to Lab.
+func LabOf(c color.Color) Lab {
+	r, g, b, _ := c.RGBA()
+
+	x, y, z := xyz(linear(r), linear(g), linear(b))
+
+	fx, fy, fz := labF(x/0.95047), labF(y), labF(z/1.08883)
+
+	return Lab{L: 116*fy - 16, A: 500 * (fx - fy), B: 200 * (fy - fz)}
+}
+
+// RGB converts l back to sRGB, clamping what falls out of its gamut.
+func (l Lab) RGB() color.NRGBA {
+	fy := (l.L + 16) / 116
+	fx, fz := fy+l.A/500, fy-l.B/200
+
+	x, y, z := 0.95047*labFInv(fx), labFInv(fy), 1.08883*labFInv(fz)
+
+	r := 3.2404542*x - 1.5371385*y - 0.4985314*z
+	g := -0.9692660*x + 1.8760108*y + 0.0415560*z
+	b := 0.0556434*x - 0.2040259*y + 1.0572252*z
+
+	return color.NRGBA{R: gamma(r), G: gamma(g), B: gamma(b), A: 0xff}
+}
+
+// DeltaE is the CIE76 distance between two colors, around 2.3 is the
+// smallest difference most people notice.
+func DeltaE(a, b Lab) float64 {
+	return math.Sqrt((a.L-b.L)*(a.L-b.L) + (a.A-b.A)*(a.A-b.A) + (a.B-b.B)*(a.B-b.B))
+}
+
+func linear(v uint32) float64 {
+	c := float64(v) / 0xffff
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+func gamma(c float64) uint8 {
+	if c <= 0.0031308 {
+		c *= 12.92
+	} else {
+		c = 1.055*math.Pow(c, 1/2.4) - 0.055
+	}
+
+	return uint8(math.Round(math.Max(0, math.Min(1, c)) * 255))
+}
+
+func xyz(r, g, b float64) (float64, float64, float64) {
+	return 0.4124564*r + 0.3575761*g + 0.1804375*b,
+		0.2126729*r + 0.7151522*g + 0.0721750*b,
+		0.0193339*r + 0.1191920*g + 0.9503041*b
+}
+
+func labF(t float64) float64 {
+	if t > 216.0/24389 {
+		return math.Cbrt(t)
+	}
+
+	return (24389.0/27*t + 16) / 116
+}
+
+func labFInv(t float64) float64 {
+	if t*t*t > 216.0/24389 {
+		return t * t * t
+	}
+
+	return (116*t - 16) * 27 / 24389
+}
+
+// Palette clusters the colors of src with k-means in Lab space and returns
+// at most k swatches, the most covering first. Transparent pixels are left
+// out. The seeding is fixed, so the same image always gives the same
+// palette.
+func Palette(src image.Image, k int) []Swatch {
+	bounds := src.Bounds()
+	if k <= 0 || bounds.Empty() {
+		return nil
+	}
+
+	w, h := min(bounds.Dx(), paletteSample), min(bounds.Dy(), paletteSample)
+
+	small := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.BiLinear.Scale(small, small.Bounds(), src, bounds, draw.Src, nil)
+
+	points := make([]Lab, 0, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := small.NRGBAAt(x, y)
+			if c.A < 0x80 {
+				continue
+			}
+
+			c.A = 0xff
+			points = append(points, LabOf(c))
+		}
+	}
+
+	if len(points) == 0 {
+		return nil
+	}
+
+	centers := seedCenters(points, min(k, len(points)))
+	assigned := make([]int, len(points))
+
+	for range paletteRounds {
+		moved := false
+
+		for i, p := range points {
+			nearest := nearestCenter(centers, p)
+			if nearest != assigned[i] {
+				assigned[i], moved = nearest, true
+			}
+		}
+
+		sums := make([]Lab, len(centers))
+		counts := make([]int, len(centers))
+
+		for i, p := range points {
+			c := assigned[i]
+			sums[c].L += p.L
+			sums[c].A += p.A
+			sums[c].B += p.B
+			counts[c]++
+		}
+
+		for c := range centers {
+			if counts[c] > 0 {
+				n := float64(counts[c])
+				centers[c] = Lab{L: sums[c].L / n, A: sums[c].A / n, B: sums[c].B / n}
+			}
+		}
+
+		if !moved {
+			break
+		}
+	}
+
+	counts := make([]int, len(centers))
+	for _, c := range assigned {
+		counts[c]++
+	}
+
+	swatches := make([]Swatch, 0, len(centers))
+	for c, center := range centers {
+		if counts[c] == 0 {
+			continue
+		}
+
+		swatches = append(swatches, Swatch{
+			Color:  center.RGB(),
+			Lab:    center,
+			Weight: float64(counts[c]) / float64(len(points)),
+		})
+	}
+
+	slices.SortStableFunc(swatches, func(a, b Swatch) int {
+		switch {
+		case a.Weight > b.Weight:
+			return -1
+		case a.Weight < b.Weight:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	return swatches
+}
+
+// seedCenters picks k starting centers the k-means++ way, each next one
+// likely far from those already taken.
+func seedCenters(points []Lab, k int) []Lab {
+	rng := rand.New(rand.NewPCG(uint64(len(points)), PaletteSize))
+
+	centers := []Lab{points[rng.IntN(len(points))]}
+	distances := make([]float64, len(points))
+
+	for len(centers) < k {
+		total := 0.0
+		for i, p := range points {
+			d := DeltaE(p, centers[nearestCenter(centers, p)])
+			distances[i] = d * d
+			total += distances[i]
+		}
+
+		// every point sits on a center, there are fewer colors than k.
+		if total == 0 {
+			break
+		}
+
+		target := rng.Float64() * total
+		next := len(points) - 1
+
+		for i, d := range distances {
+			if target -= d; target <= 0 {
+				next = i
+				break
+			}
+		}
+
+		centers = append(centers, points[next])
+	}
+
+	return centers
+}
+
+func nearestCenter(centers []Lab, p Lab) int {
+	nearest, best := 0, math.Inf(1)
+
+	for i, c := range centers {
+		if d := DeltaE(c, p); d < best {
+			nearest, best = i, d
+		}
+	}
+
+	return nearest
+}
+
+// ColorBin is the Lab cube of ColorBinSize lab falls in, stored next to a
+// palette so colors near a query can be looked up without scanning.
+func ColorBin(lab Lab) string {
+	l, a, b := binOf(lab)
+
+	return binName(l, a, b)
+}
+
+// NearBins are the bin of lab and all its neighbours, every color within
+// ColorBinSize of lab falls in one of them.
+func NearBins(lab Lab) []string {
+	l, a, b := binOf(lab)
+
+	bins := make([]string, 0, 27)
+	for dl := -1; dl <= 1; dl++ {
+		for da := -1; da <= 1; da++ {
+			for db := -1; db <= 1; db++ {
+				bins = append(bins, binName(l+dl, a+da, b+db))
+			}
+		}
+	}
+
+	return bins
+}
+
+func binOf(lab Lab) (int, int, int) {
+	cell := func(v float64) int { return int(math.Floor(v / ColorBinSize)) }
+
+	return cell(lab.L), cell(lab.A), cell(lab.B)
+}
+
+func binName(l, a, b int) string {
+	return fmt.Sprintf("%d:%d:%d", l, a, b)
+}
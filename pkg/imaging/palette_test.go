@@ -0,0 +1,205 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestParseHex(t *testing.T) {
+	tests := []struct {
+		value string
+		color color.NRGBA
+		ok    bool
+	}{
+		{value: "#ff8000", color: color.NRGBA{R: 0xff, G: 0x80, A: 0xff}, ok: true},
+		{value: "0a0B0c", color: color.NRGBA{R: 0x0a, G: 0x0b, B: 0x0c, A: 0xff}, ok: true},
+		{value: "#fff"},
+		{value: "#ff80001"},
+		{value: "##ff800"},
+		{value: "#gg8000"},
+		{value: "#-f8000"},
+		{value: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := ParseHex(tt.value)
+			if ok != tt.ok || got != tt.color {
+				t.Errorf("ParseHex = %v, %v, want %v, %v", got, ok, tt.color, tt.ok)
+			}
+		})
+	}
+}
+
+func TestLab(t *testing.T) {
+	tests := []struct {
+		name  string
+		color color.NRGBA
+		lab   Lab
+	}{
+		{name: "black", color: color.NRGBA{A: 0xff}, lab: Lab{}},
+		{name: "white", color: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, lab: Lab{L: 100}},
+		{name: "red", color: color.NRGBA{R: 0xff, A: 0xff}, lab: Lab{L: 53.24, A: 80.09, B: 67.20}},
+		{name: "blue", color: color.NRGBA{B: 0xff, A: 0xff}, lab: Lab{L: 32.30, A: 79.19, B: -107.86}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lab := LabOf(tt.color)
+			if d := DeltaE(lab, tt.lab); d > 0.05 {
+				t.Errorf("LabOf = %+v, want %+v", lab, tt.lab)
+			}
+
+			if got := lab.RGB(); got != tt.color {
+				t.Errorf("RGB = %v, want %v", got, tt.color)
+			}
+		})
+	}
+
+	rng := rand.New(rand.NewPCG(5, 6))
+
+	for i := 0; i < 1000; i++ {
+		c := color.NRGBA{R: uint8(rng.IntN(256)), G: uint8(rng.IntN(256)), B: uint8(rng.IntN(256)), A: 0xff}
+
+		if got := LabOf(c).RGB(); got != c {
+			t.Fatalf("LabOf(%v).RGB() = %v", c, got)
+		}
+	}
+}
+
+// stripes fills img with bands of the colors, each as wide as its share.
+func stripes(w, h int, colors []color.NRGBA, shares []int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+
+	x := 0
+	for i, c := range colors {
+		end := x + w*shares[i]/100
+		for ; x < end; x++ {
+			for y := 0; y < h; y++ {
+				img.SetNRGBA(x, y, c)
+			}
+		}
+	}
+
+	return img
+}
+
+func TestPalette(t *testing.T) {
+	red := color.NRGBA{R: 0xc0, G: 0x20, B: 0x20, A: 0xff}
+	teal := color.NRGBA{R: 0x10, G: 0x80, B: 0x80, A: 0xff}
+	ivory := color.NRGBA{R: 0xf0, G: 0xe8, B: 0xd0, A: 0xff}
+
+	colors := []color.NRGBA{red, teal, ivory}
+	shares := []int{50, 30, 20}
+
+	img := stripes(640, 320, colors, shares)
+
+	swatches := Palette(img, PaletteSize)
+	if len(swatches) < len(colors) {
+		t.Fatalf("Palette gave %d swatches, want at least %d", len(swatches), len(colors))
+	}
+
+	for i, c := range colors {
+		if d := DeltaE(swatches[i].Lab, LabOf(c)); d > 3 {
+			t.Errorf("swatch %d is %s, %.1f from %v", i, swatches[i].Hex(), d, c)
+		}
+
+		if want := float64(shares[i]) / 100; math.Abs(swatches[i].Weight-want) > 0.05 {
+			t.Errorf("swatch %d covers %.2f, want about %.2f", i, swatches[i].Weight, want)
+		}
+	}
+
+	total := 0.0
+	for _, s := range swatches {
+		total += s.Weight
+	}
+
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("weights add up to %v, want 1", total)
+	}
+
+	if again := Palette(img, PaletteSize); !slices.Equal(again, swatches) {
+		t.Error("Palette of the same image differs")
+	}
+}
+
+func TestPaletteEdges(t *testing.T) {
+	solid := stripes(100, 100, []color.NRGBA{{R: 0x40, G: 0x40, B: 0x90, A: 0xff}}, []int{100})
+
+	if swatches := Palette(solid, PaletteSize); len(swatches) != 1 || swatches[0].Weight != 1 {
+		t.Errorf("Palette of one color = %+v, want a single swatch", swatches)
+	}
+
+	// the transparent half is left out.
+	half := stripes(100, 100, []color.NRGBA{{R: 0xff, A: 0xff}, {B: 0xff}}, []int{50, 50})
+	for _, s := range Palette(half, PaletteSize) {
+		if s.Color.B > s.Color.R {
+			t.Errorf("Palette counted transparent pixels: %s", s.Hex())
+		}
+	}
+
+	if got := Palette(image.NewNRGBA(image.Rect(0, 0, 10, 10)), PaletteSize); got != nil {
+		t.Errorf("Palette of a transparent image = %+v, want nil", got)
+	}
+
+	if got := Palette(solid, 0); got != nil {
+		t.Errorf("Palette of no colors = %+v, want nil", got)
+	}
+}
+
+func TestNearBins(t *testing.T) {
+	tests := []struct {
+		name string
+		lab  Lab
+		near Lab
+	}{
+		{name: "same", lab: Lab{L: 50, A: 10, B: -10}, near: Lab{L: 50, A: 10, B: -10}},
+		{name: "on a bin edge", lab: Lab{L: 40, A: 0, B: 0}, near: Lab{L: 40 - ColorBinSize + 0.001, A: 0, B: 0}},
+		{name: "across three edges", lab: Lab{L: 39.999, A: -0.001, B: 19.999}, near: Lab{L: 40.001, A: 0.001, B: 20.001}},
+		{name: "diagonal", lab: Lab{L: 30, A: 30, B: 30}, near: Lab{L: 41.5, A: 41.5, B: 41.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if d := DeltaE(tt.lab, tt.near); d >= ColorBinSize {
+				t.Fatalf("colors are %.3f apart, the case needs less than %v", d, ColorBinSize)
+			}
+
+			if bins := NearBins(tt.lab); !slices.Contains(bins, ColorBin(tt.near)) {
+				t.Errorf("NearBins(%+v) = %v, want it to hold %s", tt.lab, bins, ColorBin(tt.near))
+			}
+		})
+	}
+
+	far := Lab{L: 40 - 2*ColorBinSize, A: 0, B: 0}
+	if slices.Contains(NearBins(Lab{L: 40}), ColorBin(far)) {
+		t.Errorf("NearBins holds %s, two bins away", ColorBin(far))
+	}
+
+	bins := NearBins(Lab{L: 50})
+	if compacted := slices.Compact(slices.Sorted(slices.Values(bins))); len(bins) != 27 || len(compacted) != 27 {
+		t.Errorf("NearBins = %v, want 27 distinct bins", bins)
+	}
+}
+
+func TestNearBinsHoldEveryCloseColor(t *testing.T) {
+	rng := rand.New(rand.NewPCG(7, 8))
+
+	for i := 0; i < 10000; i++ {
+		lab := Lab{L: rng.Float64() * 100, A: rng.Float64()*256 - 128, B: rng.Float64()*256 - 128}
+
+		// a random direction at a random distance below ColorBinSize.
+		dir := Lab{L: rng.NormFloat64(), A: rng.NormFloat64(), B: rng.NormFloat64()}
+		scale := rng.Float64() * ColorBinSize * 0.9999 / DeltaE(dir, Lab{})
+		near := Lab{L: lab.L + dir.L*scale, A: lab.A + dir.A*scale, B: lab.B + dir.B*scale}
+
+		if !slices.Contains(NearBins(lab), ColorBin(near)) {
+			t.Fatalf("%+v is %.3f from %+v but not in its near bins",
+				near, DeltaE(lab, near), lab)
+		}
+	}
+}
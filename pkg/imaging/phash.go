@@ -0,0 +1,67 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"math/bits"
+	"strconv"
+
+	"golang.org/x/image/draw"
+)
+
+// HashBands is how many keys Bands splits a hash into. Two hashes closer
+// than HashBands bits share at least one of them, so MaxHashDistance is the
+// widest match an exact lookup of the bands can find.
+const (
+	HashBands       = 8
+	MaxHashDistance = HashBands - 1
+)
+
+// DHash is the difference hash of img: shrunk to 9x8 grey pixels, each bit
+// tells whether a pixel is brighter than its right neighbour. It survives
+// rescaling and recompression, unlike a hash of the bytes.
+func DHash(img image.Image) uint64 {
+	small := image.NewGray(image.Rect(0, 0, 9, 8))
+	draw.BiLinear.Scale(small, small.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var hash uint64
+
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+
+			if small.GrayAt(x, y).Y > small.GrayAt(x+1, y).Y {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash
+}
+
+// Distance is the Hamming distance of two hashes.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+func FormatHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+func ParseHash(s string) (uint64, bool) {
+	hash, err := strconv.ParseUint(s, 16, 64)
+
+	return hash, err == nil && len(s) == 16
+}
+
+// Bands splits hash into HashBands keys tagged with their position, stored
+// next to the hash they make it findable through an ordinary index.
+func Bands(hash uint64) []string {
+	bands := make([]string, HashBands)
+
+	for i := range bands {
+		bands[i] = fmt.Sprintf("%d:%02x", i, byte(hash>>(8*i)))
+	}
+
+	return bands
+}
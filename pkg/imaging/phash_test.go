@@ -0,0 +1,175 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"golang.org/x/image/draw"
+)
+
+// flip returns hash with one bit flipped in each of the given bytes.
+func flip(hash uint64, positions ...int) uint64 {
+	for _, b := range positions {
+		hash ^= 1 << (8*b + b%8)
+	}
+
+	return hash
+}
+
+func sharesBand(a, b uint64) bool {
+	bands := Bands(b)
+
+	for _, band := range Bands(a) {
+		if slices.Contains(bands, band) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestBands(t *testing.T) {
+	const hash = 0x0123456789abcdef
+
+	tests := []struct {
+		name     string
+		other    uint64
+		distance int
+		shared   bool
+	}{
+		{name: "same", other: hash, distance: 0, shared: true},
+		{name: "one byte apart", other: hash ^ 0xff, distance: 8, shared: true},
+		{name: "widest match", other: flip(hash, 0, 1, 2, 3, 4, 5, 6), distance: MaxHashDistance, shared: true},
+		{name: "widest match, other bytes", other: flip(hash, 1, 2, 3, 4, 5, 6, 7), distance: MaxHashDistance, shared: true},
+		{name: "one bit past", other: flip(hash, 0, 1, 2, 3, 4, 5, 6, 7), distance: MaxHashDistance + 1},
+		{name: "inverted", other: ^uint64(hash), distance: 64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Distance(hash, tt.other); got != tt.distance {
+				t.Fatalf("Distance = %d, want %d", got, tt.distance)
+			}
+
+			if got := sharesBand(hash, tt.other); got != tt.shared {
+				t.Errorf("share a band = %v, want %v", got, tt.shared)
+			}
+		})
+	}
+
+	// the bands are positional, equal bytes elsewhere in the hash do not
+	// make a match.
+	if sharesBand(0x0001020304050607, 0x0706050403020100) {
+		t.Error("hashes with the same bytes at other positions share a band")
+	}
+}
+
+func TestBandsFindEveryCloseHash(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+
+	for i := 0; i < 1000; i++ {
+		hash := rng.Uint64()
+
+		other := hash
+		for Distance(hash, other) < MaxHashDistance {
+			other ^= 1 << rng.IntN(64)
+		}
+
+		if !sharesBand(hash, other) {
+			t.Fatalf("%s and %s are %d bits apart but share no band",
+				FormatHash(hash), FormatHash(other), Distance(hash, other))
+		}
+	}
+}
+
+func TestParseHash(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		hash uint64
+		ok   bool
+	}{
+		{name: "zero", s: "0000000000000000", ok: true},
+		{name: "mixed", s: "0123456789abcdef", hash: 0x0123456789abcdef, ok: true},
+		{name: "upper case", s: "0123456789ABCDEF", hash: 0x0123456789abcdef, ok: true},
+		{name: "short", s: "123456789abcdef"},
+		{name: "long", s: "00123456789abcdef"},
+		{name: "not hex", s: "0123456789abcdeg"},
+		{name: "prefixed", s: "0x23456789abcdef"},
+		{name: "empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, ok := ParseHash(tt.s)
+			if ok != tt.ok || ok && hash != tt.hash {
+				t.Errorf("ParseHash = %x, %v, want %x, %v", hash, ok, tt.hash, tt.ok)
+			}
+		})
+	}
+
+	for _, hash := range []uint64{0, 1, 0x0123456789abcdef, math.MaxUint64} {
+		if got, ok := ParseHash(FormatHash(hash)); !ok || got != hash {
+			t.Errorf("ParseHash(FormatHash(%x)) = %x, %v", hash, got, ok)
+		}
+	}
+}
+
+// wavyImage has some structure for DHash to pick up, unlike a plain
+// gradient.
+func wavyImage(w, h int, phase float64) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fx, fy := float64(x)/float64(w), float64(y)/float64(h)
+			v := uint8(127 + 100*math.Sin(9*fx+phase)*math.Cos(7*fy+phase))
+			img.SetRGBA(x, y, color.RGBA{R: v, G: v / 2, B: 255 - v, A: 0xff})
+		}
+	}
+
+	return img
+}
+
+func TestDHash(t *testing.T) {
+	original := wavyImage(1280, 720, 0)
+	hash := DHash(original)
+
+	small := image.NewRGBA(image.Rect(0, 0, 320, 180))
+	draw.CatmullRom.Scale(small, small.Bounds(), original, original.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, original, &jpeg.Options{Quality: 60}); err != nil {
+		t.Fatal(err)
+	}
+
+	recompressed, err := jpeg.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		img  image.Image
+		near bool
+	}{
+		{name: "rescaled", img: small, near: true},
+		{name: "recompressed", img: recompressed, near: true},
+		{name: "other picture", img: wavyImage(1280, 720, 2)},
+		{name: "noise", img: texturedImage(1280, 720)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			distance := Distance(hash, DHash(tt.img))
+			if near := distance <= MaxHashDistance; near != tt.near {
+				t.Errorf("Distance = %d, want within %d: %v", distance, MaxHashDistance, tt.near)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+
+	"golang.org/x/image/draw"
+)
+
+const RenditionQuality = 85
+
+// Spec is one size an image is rendered at.
+type Spec struct {
+	Name   string
+	Width  int
+	Height int
+}
+
+// WallpaperSpecs are the renditions every wallpaper gets, the first one is
+// rendered even from images smaller than it.
+var WallpaperSpecs = []Spec{
+	{Name: "thumb", Width: 320, Height: 180},
+	{Name: "phone", Width: 1080, Height: 1920},
+	{Name: "1080p", Width: 1920, Height: 1080},
+	{Name: "1440p", Width: 2560, Height: 1440},
+	{Name: "4k", Width: 3840, Height: 2160},
+}
+
+// Covers reports whether src is big enough to be rendered at spec without
+// upscaling.
+func (spec Spec) Covers(src image.Rectangle) bool {
+	return src.Dx() >= spec.Width && src.Dy() >= spec.Height
+}
+
+// Fill scales src to cover width x height and crops the overflow evenly from
+// both sides, so the result has exactly the requested size.
+func Fill(src image.Image, width, height int) image.Image {
+	bounds := src.Bounds()
+
+	crop := bounds
+	if bounds.Dx()*height > bounds.Dy()*width {
+		w := bounds.Dy() * width / height
+		crop.Min.X += (bounds.Dx() - w) / 2
+		crop.Max.X = crop.Min.X + w
+	} else {
+		h := bounds.Dx() * height / width
+		crop.Min.Y += (bounds.Dy() - h) / 2
+		crop.Max.Y = crop.Min.Y + h
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+
+	return dst
+}
+
+// Render fills src into spec and encodes it as JPEG.
+func Render(src image.Image, spec Spec) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := jpeg.Encode(&buf, Fill(src, spec.Width, spec.Height), &jpeg.Options{Quality: RenditionQuality}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,93 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"io"
+	"net/http"
+)
+
+// SniffLength is how much of an upload http.DetectContentType looks at.
+const SniffLength = 512
+
+var (
+	ErrInvalidImage      = errors.New("invalid image")
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrTooLarge          = errors.New("image is too large")
+	ErrTooManyPixels     = errors.New("image has too many pixels")
+)
+
+// formats maps the sniffed content types we accept to the name the image
+// package registers the decoder under.
+var formats = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+var extensions = map[string]string{
+	"jpeg": ".jpg",
+	"png":  ".png",
+	"gif":  ".gif",
+}
+
+// Limits caps an upload, a zero field is not checked.
+type Limits struct {
+	MaxBytes  int64
+	MaxPixels int64
+}
+
+// Info describes an image that passed Validate.
+type Info struct {
+	Format      string
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Extension is the file extension matching the detected format, uploads
+// are named after it rather than after whatever the client called them.
+func (i Info) Extension() string {
+	return extensions[i.Format]
+}
+
+// Validate sniffs the content of r, which holds size bytes, and checks it
+// against limits. Only the header is decoded, so a decompression bomb is
+// refused before its pixels are ever allocated.
+func Validate(r io.Reader, size int64, limits Limits) (Info, error) {
+	if limits.MaxBytes > 0 && size > limits.MaxBytes {
+		return Info{}, ErrTooLarge
+	}
+
+	head := make([]byte, SniffLength)
+
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return Info{}, ErrInvalidImage
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+
+	format, ok := formats[contentType]
+	if !ok {
+		return Info{}, ErrUnsupportedFormat
+	}
+
+	cfg, decoded, err := image.DecodeConfig(io.MultiReader(bytes.NewReader(head), r))
+	if err != nil || decoded != format || cfg.Width <= 0 || cfg.Height <= 0 {
+		return Info{}, ErrInvalidImage
+	}
+
+	if limits.MaxPixels > 0 && int64(cfg.Width)*int64(cfg.Height) > limits.MaxPixels {
+		return Info{}, ErrTooManyPixels
+	}
+
+	return Info{
+		Format:      format,
+		ContentType: contentType,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+	}, nil
+}
@@ -0,0 +1,363 @@
+package imaging
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"math"
+	"math/rand/v2"
+	"strings"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// Corners a visible mark may sit in.
+const (
+	CornerTopLeft     = "top-left"
+	CornerTopRight    = "top-right"
+	CornerBottomLeft  = "bottom-left"
+	CornerBottomRight = "bottom-right"
+)
+
+const (
+	// MarkSide is the side of the square the invisible mark is written in.
+	// Every image is scaled to it to embed or read the mark, so the mark
+	// survives resizing as well as recompression.
+	MarkSide  = 512
+	markBlock = 8
+	// MarkStrength is the gap kept between the two coefficients carrying a
+	// bit, higher survives more abuse and shows more.
+	MarkStrength = 24
+
+	// marks carry a downloader username of the [a-z0-9_-] alphabet packed
+	// to six bits a character.
+	markAlphabet    = "abcdefghijklmnopqrstuvwxyz0123456789_-"
+	MaxMarkUsername = 32
+	markBytes       = 12 + 1 + MaxMarkUsername*6/8 + 4
+	markBits        = markBytes * 8
+)
+
+// the two mid-frequency coefficients of a block whose order is a bit.
+var markCoefficients = [2][2]int{{2, 3}, {3, 2}}
+
+// Mark is what the invisible watermark carries: the id of the wallpaper
+// and who downloaded it, empty for anonymous downloads.
+type Mark struct {
+	Wallpaper  [12]byte
+	Downloader string
+}
+
+func (m Mark) encode() ([]byte, bool) {
+	if len(m.Downloader) > MaxMarkUsername {
+		return nil, false
+	}
+
+	payload := make([]byte, 0, markBytes)
+	payload = append(payload, m.Wallpaper[:]...)
+	payload = append(payload, byte(len(m.Downloader)))
+
+	var (
+		packed [MaxMarkUsername * 6 / 8]byte
+		bit    int
+	)
+
+	for _, r := range m.Downloader {
+		symbol := strings.IndexRune(markAlphabet, r)
+		if symbol < 0 {
+			return nil, false
+		}
+
+		for i := 5; i >= 0; i-- {
+			if symbol>>i&1 == 1 {
+				packed[bit/8] |= 0x80 >> (bit % 8)
+			}
+			bit++
+		}
+	}
+
+	payload = append(payload, packed[:]...)
+
+	return binary.BigEndian.AppendUint32(payload, crc32.ChecksumIEEE(payload)), true
+}
+
+func decodeMark(payload []byte) (Mark, bool) {
+	body := payload[:len(payload)-4]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(payload[len(body):]) {
+		return Mark{}, false
+	}
+
+	var mark Mark
+	copy(mark.Wallpaper[:], body[:12])
+
+	length := int(body[12])
+	if length > MaxMarkUsername {
+		return Mark{}, false
+	}
+
+	packed := body[13:]
+
+	var name strings.Builder
+	for c := 0; c < length; c++ {
+		symbol := 0
+		for i := 0; i < 6; i++ {
+			bit := c*6 + i
+			symbol = symbol<<1 | int(packed[bit/8]>>(7-bit%8)&1)
+		}
+
+		if symbol >= len(markAlphabet) {
+			return Mark{}, false
+		}
+
+		name.WriteByte(markAlphabet[symbol])
+	}
+
+	mark.Downloader = name.String()
+
+	return mark, true
+}
+
+// markLayout assigns every block of the mark square the payload bit it
+// carries. The order is derived from key, without it the blocks can not be
+// told apart.
+func markLayout(key string) []int {
+	seed := sha256.Sum256([]byte(key))
+	rng := rand.New(rand.NewPCG(binary.BigEndian.Uint64(seed[:8]), binary.BigEndian.Uint64(seed[8:16])))
+
+	blocks := (MarkSide / markBlock) * (MarkSide / markBlock)
+
+	layout := make([]int, blocks)
+	for i, block := range rng.Perm(blocks) {
+		layout[block] = i % markBits
+	}
+
+	return layout
+}
+
+// markPlane is the luminance of img scaled to the mark square.
+func markPlane(img image.Image) [][]float64 {
+	gray := image.NewGray(image.Rect(0, 0, MarkSide, MarkSide))
+	draw.BiLinear.Scale(gray, gray.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	plane := make([][]float64, MarkSide)
+	for y := range plane {
+		plane[y] = make([]float64, MarkSide)
+		for x := range plane[y] {
+			plane[y][x] = float64(gray.GrayAt(x, y).Y)
+		}
+	}
+
+	return plane
+}
+
+// EmbedMark hides mark in a copy of img. The mark is written into the
+// order of two DCT coefficients of every block of the mark square, spread
+// back over the full image as a change of brightness.
+func EmbedMark(img image.Image, mark Mark, key string) (*image.RGBA, bool) {
+	payload, ok := mark.encode()
+	if !ok {
+		return nil, false
+	}
+
+	plane := markPlane(img)
+	layout := markLayout(key)
+
+	// the change is kept around 1<<15 so it fits an unsigned image.
+	residual := image.NewGray16(image.Rect(0, 0, MarkSide, MarkSide))
+	draw.Draw(residual, residual.Bounds(), image.NewUniform(color.Gray16{Y: 1 << 15}), image.Point{}, draw.Src)
+
+	perRow := MarkSide / markBlock
+
+	for block, bit := range layout {
+		bx, by := block%perRow*markBlock, block/perRow*markBlock
+
+		var pixels [markBlock][markBlock]float64
+		for y := range pixels {
+			for x := range pixels[y] {
+				pixels[y][x] = plane[by+y][bx+x]
+			}
+		}
+
+		coeffs := dct(pixels)
+
+		a, b := markCoefficients[0], markCoefficients[1]
+		gap := coeffs[a[0]][a[1]] - coeffs[b[0]][b[1]]
+
+		want := MarkStrength
+		if payload[bit/8]>>(7-bit%8)&1 == 0 {
+			want = -want
+		}
+
+		if (want > 0 && gap >= float64(want)) || (want < 0 && gap <= float64(want)) {
+			continue
+		}
+
+		shift := (float64(want) - gap) / 2
+		coeffs[a[0]][a[1]] += shift
+		coeffs[b[0]][b[1]] -= shift
+
+		marked := idct(coeffs)
+
+		for y := range marked {
+			for x := range marked[y] {
+				delta := (marked[y][x] - pixels[y][x]) * 128
+				residual.SetGray16(bx+x, by+y, color.Gray16{Y: uint16(math.Max(0, math.Min(65535, 32768+delta)))})
+			}
+		}
+	}
+
+	bounds := img.Bounds()
+
+	spread := image.NewGray16(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.BiLinear.Scale(spread, spread.Bounds(), residual, residual.Bounds(), draw.Src, nil)
+
+	out := image.NewRGBA(spread.Bounds())
+	draw.Draw(out, out.Bounds(), img, bounds.Min, draw.Src)
+
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			delta := (float64(spread.Gray16At(x, y).Y) - 32768) / 128
+			if delta == 0 {
+				continue
+			}
+
+			i := out.PixOffset(x, y)
+			for c := 0; c < 3; c++ {
+				out.Pix[i+c] = uint8(math.Max(0, math.Min(255, math.Round(float64(out.Pix[i+c])+delta))))
+			}
+		}
+	}
+
+	return out, true
+}
+
+// ReadMark looks for a mark embedded with key, every bit is decided by all
+// the blocks carrying it so a damaged block is outvoted.
+func ReadMark(img image.Image, key string) (Mark, bool) {
+	plane := markPlane(img)
+	layout := markLayout(key)
+
+	votes := make([]float64, markBits)
+	perRow := MarkSide / markBlock
+
+	for block, bit := range layout {
+		bx, by := block%perRow*markBlock, block/perRow*markBlock
+
+		var pixels [markBlock][markBlock]float64
+		for y := range pixels {
+			for x := range pixels[y] {
+				pixels[y][x] = plane[by+y][bx+x]
+			}
+		}
+
+		coeffs := dct(pixels)
+
+		a, b := markCoefficients[0], markCoefficients[1]
+		votes[bit] += math.Max(-MarkStrength, math.Min(MarkStrength, coeffs[a[0]][a[1]]-coeffs[b[0]][b[1]]))
+	}
+
+	payload := make([]byte, markBytes)
+	for bit, vote := range votes {
+		if vote > 0 {
+			payload[bit/8] |= 0x80 >> (bit % 8)
+		}
+	}
+
+	return decodeMark(payload)
+}
+
+func dct(block [markBlock][markBlock]float64) [markBlock][markBlock]float64 {
+	var out [markBlock][markBlock]float64
+
+	for u := 0; u < markBlock; u++ {
+		for v := 0; v < markBlock; v++ {
+			sum := 0.0
+			for y := 0; y < markBlock; y++ {
+				for x := 0; x < markBlock; x++ {
+					sum += block[y][x] * dctBasis[u][y] * dctBasis[v][x]
+				}
+			}
+
+			out[u][v] = sum
+		}
+	}
+
+	return out
+}
+
+func idct(coeffs [markBlock][markBlock]float64) [markBlock][markBlock]float64 {
+	var out [markBlock][markBlock]float64
+
+	for y := 0; y < markBlock; y++ {
+		for x := 0; x < markBlock; x++ {
+			sum := 0.0
+			for u := 0; u < markBlock; u++ {
+				for v := 0; v < markBlock; v++ {
+					sum += coeffs[u][v] * dctBasis[u][y] * dctBasis[v][x]
+				}
+			}
+
+			out[y][x] = sum
+		}
+	}
+
+	return out
+}
+
+// dctBasis is the orthonormal DCT-II basis, dctBasis[u][x] weighs pixel x
+// for frequency u.
+var dctBasis = func() (basis [markBlock][markBlock]float64) {
+	for u := 0; u < markBlock; u++ {
+		scale := math.Sqrt(2.0 / markBlock)
+		if u == 0 {
+			scale = math.Sqrt(1.0 / markBlock)
+		}
+
+		for x := 0; x < markBlock; x++ {
+			basis[u][x] = scale * math.Cos(float64(2*x+1)*float64(u)*math.Pi/(2*markBlock))
+		}
+	}
+
+	return basis
+}()
+
+// DrawCorner writes text into a corner of img, white at opacity over a
+// soft shadow so it reads on any background.
+func DrawCorner(img *image.RGBA, text, corner string, opacity float64) error {
+	bounds := img.Bounds()
+
+	size := math.Max(MinCaptionSize, float64(bounds.Dy())/40)
+
+	face, err := captionFace(size)
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+
+	metrics := face.Metrics()
+	width := font.MeasureString(face, text)
+	margin := fixed.I(int(size))
+
+	dot := fixed.Point26_6{X: fixed.I(bounds.Min.X) + margin, Y: fixed.I(bounds.Min.Y) + margin + metrics.Ascent}
+
+	if corner == CornerTopRight || corner == CornerBottomRight {
+		dot.X = fixed.I(bounds.Max.X) - margin - width
+	}
+
+	if corner == CornerBottomLeft || corner == CornerBottomRight || corner == "" {
+		dot.Y = fixed.I(bounds.Max.Y) - margin - metrics.Descent
+	}
+
+	alpha := uint8(math.Max(0, math.Min(1, opacity)) * 255)
+
+	shadow := font.Drawer{Dst: img, Src: image.NewUniform(color.NRGBA{A: alpha / 2}), Face: face, Dot: dot.Add(fixed.P(1, 1))}
+	shadow.DrawString(text)
+
+	mark := font.Drawer{Dst: img, Src: image.NewUniform(color.NRGBA{R: 255, G: 255, B: 255, A: alpha}), Face: face, Dot: dot}
+	mark.DrawString(text)
+
+	return nil
+}
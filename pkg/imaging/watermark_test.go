@@ -0,0 +1,130 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func TestMarkEncoding(t *testing.T) {
+	wallpaper := [12]byte{0x65, 0x1f, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+
+	tests := []struct {
+		name string
+		mark Mark
+		ok   bool
+	}{
+		{name: "anonymous", mark: Mark{Wallpaper: wallpaper}, ok: true},
+		{name: "downloader", mark: Mark{Wallpaper: wallpaper, Downloader: "reader_1-x"}, ok: true},
+		{name: "longest downloader", mark: Mark{Wallpaper: wallpaper, Downloader: strings.Repeat("-", MaxMarkUsername)}, ok: true},
+		{name: "downloader too long", mark: Mark{Wallpaper: wallpaper, Downloader: strings.Repeat("a", MaxMarkUsername+1)}},
+		{name: "upper case", mark: Mark{Wallpaper: wallpaper, Downloader: "Reader"}},
+		{name: "outside the alphabet", mark: Mark{Wallpaper: wallpaper, Downloader: "reader.1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, ok := tt.mark.encode()
+			if ok != tt.ok {
+				t.Fatalf("encode ok = %v, want %v", ok, tt.ok)
+			}
+
+			if !ok {
+				return
+			}
+
+			if len(payload) != markBytes {
+				t.Fatalf("payload is %d bytes, want %d", len(payload), markBytes)
+			}
+
+			got, ok := decodeMark(payload)
+			if !ok || got != tt.mark {
+				t.Errorf("decodeMark = %+v, %v, want %+v, true", got, ok, tt.mark)
+			}
+		})
+	}
+}
+
+func TestDecodeMarkRejects(t *testing.T) {
+	payload, ok := Mark{Downloader: "reader"}.encode()
+	if !ok {
+		t.Fatal("encode failed")
+	}
+
+	for bit := 0; bit < markBits; bit++ {
+		damaged := append([]byte{}, payload...)
+		damaged[bit/8] ^= 0x80 >> (bit % 8)
+
+		if _, ok := decodeMark(damaged); ok {
+			t.Fatalf("decodeMark accepted a payload with bit %d flipped", bit)
+		}
+	}
+
+	if _, ok := decodeMark(make([]byte, markBytes)); ok {
+		t.Error("decodeMark accepted an empty payload")
+	}
+}
+
+// texturedImage looks like a photo to the mark, smooth gradients with some
+// noise on top.
+func texturedImage(w, h int) *image.RGBA {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			noise := rng.IntN(24)
+			img.SetRGBA(x, y, color.RGBA{
+				R: uint8(60 + x*120/w + noise),
+				G: uint8(40 + y*140/h + noise),
+				B: uint8(90 + (x+y)*60/(w+h) + noise),
+				A: 0xff,
+			})
+		}
+	}
+
+	return img
+}
+
+func TestMarkSurvivesJPEG(t *testing.T) {
+	const key = "watermark-test-key"
+
+	mark := Mark{
+		Wallpaper:  [12]byte{0x66, 0x0a, 0x1b, 0x2c, 0x3d, 0x4e, 0x5f, 0x60, 0x71, 0x82, 0x93, 0xa4},
+		Downloader: "night_reader",
+	}
+
+	for _, size := range []image.Point{{X: 1280, Y: 720}, {X: 600, Y: 900}} {
+		marked, ok := EmbedMark(texturedImage(size.X, size.Y), mark, key)
+		if !ok {
+			t.Fatalf("%v: EmbedMark failed", size)
+		}
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, marked, &jpeg.Options{Quality: 85}); err != nil {
+			t.Fatal(err)
+		}
+
+		decoded, err := jpeg.Decode(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, ok := ReadMark(decoded, key)
+		if !ok || got != mark {
+			t.Fatalf("%v: ReadMark = %+v, %v, want %+v, true", size, got, ok, mark)
+		}
+
+		if _, ok = ReadMark(decoded, "another key"); ok {
+			t.Errorf("%v: ReadMark found the mark with another key", size)
+		}
+	}
+
+	if _, ok := ReadMark(texturedImage(640, 480), key); ok {
+		t.Error("ReadMark found a mark in an unmarked image")
+	}
+}
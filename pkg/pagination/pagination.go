@@ -0,0 +1,146 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Sort string
+
+const (
+	SortNewest  Sort = "newest"
+	SortOldest  Sort = "oldest"
+	SortPopular Sort = "popular"
+	// SortNearest orders lists ranked by a distance to what was asked for,
+	// see NewRankedRequest.
+	SortNearest Sort = "nearest"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidLimit  = errors.New("invalid limit")
+)
+
+type (
+	// Cursor is the position of the last item of a page, it is handed to
+	// clients as an opaque token and only makes sense with the same sort.
+	Cursor struct {
+		Sort      Sort               `json:"s"`
+		Timestamp time.Time          `json:"t"`
+		Views     int64              `json:"v"`
+		Distance  float64            `json:"d,omitempty"`
+		ID        primitive.ObjectID `json:"id"`
+	}
+
+	Request struct {
+		Sort  Sort
+		Limit int64
+		After *Cursor
+	}
+
+	Page[T any] struct {
+		Items      []T    `json:"items"`
+		NextCursor string `json:"next_cursor,omitempty"`
+	}
+)
+
+func NewRequest(sort, cursor, limit string) (Request, error) {
+	req := Request{Sort: SortNewest}
+
+	if sort != "" {
+		req.Sort = Sort(sort)
+	}
+
+	switch req.Sort {
+	case SortNewest, SortOldest, SortPopular:
+	default:
+		return Request{}, ErrInvalidSort
+	}
+
+	return newRequest(req.Sort, cursor, limit)
+}
+
+// NewRankedRequest pages through a list ordered by distance, closest first.
+func NewRankedRequest(cursor, limit string) (Request, error) {
+	return newRequest(SortNearest, cursor, limit)
+}
+
+func newRequest(sort Sort, cursor, limit string) (Request, error) {
+	req := Request{
+		Sort:  sort,
+		Limit: DefaultLimit,
+	}
+
+	if limit != "" {
+		n, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || n < 1 {
+			return Request{}, ErrInvalidLimit
+		}
+
+		req.Limit = min(n, MaxLimit)
+	}
+
+	if cursor != "" {
+		after, err := Decode(cursor)
+		if err != nil || after.Sort != req.Sort {
+			return Request{}, ErrInvalidCursor
+		}
+
+		req.After = after
+	}
+
+	return req, nil
+}
+
+func (c Cursor) Encode() string {
+	payload, _ := sonic.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+func Decode(token string) (*Cursor, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+
+	if err = sonic.Unmarshal(payload, &cursor); err != nil || cursor.ID.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// Build cuts items fetched with one extra element down to a page, the extra
+// element only tells whether there is a next page.
+func Build[T any](items []T, req Request, position func(T) Cursor) *Page[T] {
+	page := &Page[T]{Items: items}
+
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+
+	if int64(len(items)) > req.Limit {
+		page.Items = items[:req.Limit]
+
+		next := position(page.Items[len(page.Items)-1])
+		next.Sort = req.Sort
+
+		page.NextCursor = next.Encode()
+	}
+
+	return page
+}
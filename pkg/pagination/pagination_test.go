@@ -0,0 +1,129 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id, _ := primitive.ObjectIDFromHex("651f00010203040506070809")
+	at := time.Date(2024, 3, 9, 18, 30, 15, 250_000_000, time.UTC)
+
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{name: "newest", cursor: Cursor{Sort: SortNewest, Timestamp: at, ID: id}},
+		{name: "oldest", cursor: Cursor{Sort: SortOldest, Timestamp: at, ID: id}},
+		{name: "popular", cursor: Cursor{Sort: SortPopular, Timestamp: at, Views: 1234, ID: id}},
+		{name: "nearest", cursor: Cursor{Sort: SortNearest, Distance: 17.25, ID: id}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(tt.cursor.Encode())
+			if err != nil {
+				t.Fatalf("Decode = %v, want nil", err)
+			}
+
+			if got.Sort != tt.cursor.Sort || !got.Timestamp.Equal(tt.cursor.Timestamp) ||
+				got.Views != tt.cursor.Views || got.Distance != tt.cursor.Distance || got.ID != tt.cursor.ID {
+				t.Errorf("Decode = %+v, want %+v", *got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestNewRequestCursor(t *testing.T) {
+	id, _ := primitive.ObjectIDFromHex("651f00010203040506070809")
+	newest := Cursor{Sort: SortNewest, Timestamp: time.Unix(1700000000, 0), ID: id}.Encode()
+
+	tests := []struct {
+		name   string
+		sort   string
+		cursor string
+		err    error
+	}{
+		{name: "no cursor", sort: "popular"},
+		{name: "same sort", sort: "newest", cursor: newest},
+		{name: "default sort", cursor: newest},
+		{name: "other sort", sort: "oldest", cursor: newest, err: ErrInvalidCursor},
+		{name: "not base64", cursor: "not a cursor!", err: ErrInvalidCursor},
+		{name: "padded", cursor: newest + "==", err: ErrInvalidCursor},
+		{name: "truncated", cursor: newest[:len(newest)-4], err: ErrInvalidCursor},
+		{name: "not json", cursor: base64.RawURLEncoding.EncodeToString([]byte("newest")), err: ErrInvalidCursor},
+		{name: "no id", cursor: Cursor{Sort: SortNewest, Timestamp: time.Unix(1700000000, 0)}.Encode(), err: ErrInvalidCursor},
+		{name: "bad id", cursor: base64.RawURLEncoding.EncodeToString([]byte(`{"s":"newest","t":"2024-03-09T18:30:15Z","v":0,"id":"xyz"}`)), err: ErrInvalidCursor},
+		{name: "unknown sort", sort: "random", err: ErrInvalidSort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := NewRequest(tt.sort, tt.cursor, "")
+			if err != tt.err {
+				t.Fatalf("NewRequest err = %v, want %v", err, tt.err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if (req.After != nil) != (tt.cursor != "") {
+				t.Errorf("NewRequest After = %+v, want a cursor: %v", req.After, tt.cursor != "")
+			}
+		})
+	}
+
+	if _, err := NewRankedRequest(newest, ""); err != ErrInvalidCursor {
+		t.Errorf("NewRankedRequest with a newest cursor err = %v, want %v", err, ErrInvalidCursor)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	position := func(n int) Cursor {
+		return Cursor{Views: int64(n), ID: primitive.NewObjectID()}
+	}
+
+	req := Request{Sort: SortPopular, Limit: 3}
+
+	tests := []struct {
+		name  string
+		items []int
+		want  int
+		next  bool
+	}{
+		{name: "empty", want: 0},
+		{name: "short page", items: []int{1, 2}, want: 2},
+		{name: "full page", items: []int{1, 2, 3}, want: 3},
+		{name: "one extra", items: []int{1, 2, 3, 4}, want: 3, next: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := Build(tt.items, req, position)
+			if page.Items == nil || len(page.Items) != tt.want {
+				t.Fatalf("Build items = %v, want %d of them", page.Items, tt.want)
+			}
+
+			if (page.NextCursor != "") != tt.next {
+				t.Fatalf("Build next cursor = %q, want one: %v", page.NextCursor, tt.next)
+			}
+
+			if !tt.next {
+				return
+			}
+
+			next, err := Decode(page.NextCursor)
+			if err != nil {
+				t.Fatalf("Decode next cursor = %v", err)
+			}
+
+			if next.Sort != req.Sort || next.Views != int64(tt.items[req.Limit-1]) {
+				t.Errorf("next cursor = %+v, want the position of the last item sorted by %s", *next, req.Sort)
+			}
+		})
+	}
+}
@@ -6,22 +6,51 @@ import (
 	"github.com/bytedance/sonic"
 	"github.com/nats-io/nats.go"
 	"github.com/osamikoyo/dark-fantasy-land/pkg/logger"
+	"github.com/osamikoyo/dark-fantasy-land/pkg/streams"
 	"go.uber.org/zap"
 )
 
 type Producer struct {
 	client *nats.Conn
+	js     nats.JetStreamContext
 	logger *logger.Logger
 }
 
 func NewProducer(client *nats.Conn, logger *logger.Logger) *Producer {
+	// JetStream only fails on conflicting options and none are passed.
+	js, _ := client.JetStream()
+
 	return &Producer{
 		client: client,
+		js:     js,
 		logger: logger,
 	}
 }
 
-func (p *Producer) SendToCensor(queue string, value interface{}) error {
+// EnsureStream creates the streams the outbox publishes to, the one that
+// keeps content for the censor until it is read and the one of image jobs.
+// Publishing fails while there is none.
+func (p *Producer) EnsureStream() error {
+	if err := streams.Ensure(p.js, streams.Config(streams.Censor, streams.CensorSubjects)); err != nil {
+		p.logger.Error("failed ensure censor stream", zap.Error(err))
+
+		return err
+	}
+
+	if err := streams.Ensure(p.js, streams.Config(streams.Images, streams.ImageSubjects)); err != nil {
+		p.logger.Error("failed ensure images stream", zap.Error(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// SendToCensor returns once the stream has stored the value, so a censor
+// that is down picks it up later instead of missing it. A non-empty id is
+// sent as the message id, the stream then drops another copy published
+// under it within its duplicate window.
+func (p *Producer) SendToCensor(queue, id string, value interface{}) error {
 	if value == nil {
 		return errors.New("nil input")
 	}
@@ -36,10 +65,16 @@ func (p *Producer) SendToCensor(queue string, value interface{}) error {
 		return err
 	}
 
-	if err = p.client.Publish(queue, payload); err != nil {
+	var opts []nats.PubOpt
+	if id != "" {
+		opts = append(opts, nats.MsgId(id))
+	}
+
+	if _, err = p.js.Publish(queue, payload, opts...); err != nil {
 		p.logger.Error("failed publish value",
 			zap.Any("value", value),
 			zap.String("queue", queue),
+			zap.String("id", id),
 			zap.Error(err))
 
 		return err
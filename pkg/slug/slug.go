@@ -0,0 +1,62 @@
+package slug
+
+import (
+	"encoding/hex"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MaxBaseLength = 60
+	SuffixBytes   = 4
+)
+
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "h", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "",
+	'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+}
+
+// Make builds a URL-safe slug from a human readable base and the document id.
+// The id suffix keeps slugs unique even when two titles are the same.
+func Make(base, fallback string, id primitive.ObjectID) string {
+	out := normalize(base)
+	if out == "" {
+		out = fallback
+	}
+
+	return out + "-" + hex.EncodeToString(id[len(id)-SuffixBytes:])
+}
+
+func normalize(base string) string {
+	var (
+		b    strings.Builder
+		dash bool
+	)
+
+	for _, r := range strings.ToLower(base) {
+		if b.Len() >= MaxBaseLength {
+			break
+		}
+
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+			dash = false
+		case cyrillic[r] != "":
+			b.WriteString(cyrillic[r])
+			dash = false
+		default:
+			if !dash && b.Len() > 0 {
+				b.WriteByte('-')
+				dash = true
+			}
+		}
+	}
+
+	return strings.TrimRight(b.String(), "-")
+}
@@ -3,10 +3,7 @@ package storage
 import (
 	"bytes"
 	"context"
-	"image/jpeg"
-	"mime/multipart"
-	"os"
-	"path/filepath"
+	"io"
 	"time"
 
 	"github.com/minio/minio-go/v7"
@@ -15,10 +12,6 @@ import (
 	"go.uber.org/zap"
 )
 
-const (
-	CommpressedQuality = 800
-)
-
 type Storage struct {
 	logger  *logger.Logger
 	client  *minio.Client
@@ -38,39 +31,43 @@ func (s *Storage) context() (context.Context, context.CancelFunc) {
 	return context.WithTimeout(context.Background(), s.timeout)
 }
 
-func (s *Storage) UploadFile(file *multipart.FileHeader, bucketName string) error {
+func (s *Storage) EnsureBuckets(buckets ...string) error {
 	ctx, cancel := s.context()
 	defer cancel()
 
-	src, err := file.Open()
-	if err != nil {
-		s.logger.Error("failed upload file", zap.Error(err))
+	for _, bucket := range buckets {
+		exists, err := s.client.BucketExists(ctx, bucket)
+		if err != nil {
+			s.logger.Error("failed check bucket",
+				zap.String("bucket_name", bucket),
+				zap.Error(err))
 
-		return err
-	}
-	defer src.Close()
+			return err
+		}
 
-	_, err = s.client.PutObject(
-		ctx,
-		bucketName,
-		file.Filename,
-		src,
-		file.Size,
-		minio.PutObjectOptions{ContentType: "application/octet-stream"},
-	)
-	if err != nil {
-		s.logger.Error("failed put object", zap.Error(err))
+		if exists {
+			continue
+		}
+
+		if err = s.client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{}); err != nil {
+			s.logger.Error("failed make bucket",
+				zap.String("bucket_name", bucket),
+				zap.Error(err))
+
+			return err
+		}
+
+		s.logger.Info("bucket created", zap.String("bucket_name", bucket))
 	}
 
 	return nil
 }
 
+// DownloadFile returns a lazy object reader, it must not be bound to a
+// timeout context because the body is streamed after the call returns.
 func (s *Storage) DownloadFile(filename, bucketName string) (*minio.Object, error) {
-	ctx, cancel := s.context()
-	defer cancel()
-
 	obj, err := s.client.GetObject(
-		ctx,
+		context.Background(),
 		bucketName,
 		filename,
 		minio.GetObjectOptions{},
@@ -87,46 +84,124 @@ func (s *Storage) DownloadFile(filename, bucketName string) (*minio.Object, erro
 	return obj, nil
 }
 
-func (s *Storage) UploadAndCommpress(fileHeader *multipart.FileHeader, bucket string) error {
-	file, err := fileHeader.Open()
+// OpenFile is DownloadFile for callers that only read the object.
+func (s *Storage) OpenFile(filename, bucketName string) (io.ReadCloser, error) {
+	obj, err := s.DownloadFile(filename, bucketName)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer file.Close()
 
-	img, err := jpeg.Decode(file)
-	if err != nil {
-		return err
+	return obj, nil
+}
+
+func (s *Storage) ObjectExists(filename, bucketName string) (bool, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	_, err := s.client.StatObject(ctx, bucketName, filename, minio.StatObjectOptions{})
+	if err == nil {
+		return true, nil
 	}
 
-	var buf bytes.Buffer
-	err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: CommpressedQuality})
-	if err != nil {
-		return err
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return false, nil
 	}
 
-	tmpFile, err := os.CreateTemp("", "compressed-*.jpg")
-	if err != nil {
-		return err
+	s.logger.Error("failed stat object",
+		zap.String("filename", filename),
+		zap.String("bucket_name", bucketName),
+		zap.Error(err))
+
+	return false, err
+}
+
+// ListFiles returns the names of every object in the bucket.
+func (s *Storage) ListFiles(bucketName string) ([]string, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	var names []string
+
+	for obj := range s.client.ListObjects(ctx, bucketName, minio.ListObjectsOptions{}) {
+		if obj.Err != nil {
+			s.logger.Error("failed list files",
+				zap.String("bucket_name", bucketName),
+				zap.Error(obj.Err))
+
+			return nil, obj.Err
+		}
+
+		names = append(names, obj.Key)
 	}
-	defer tmpFile.Close()
 
-	_, err = tmpFile.Write(buf.Bytes())
-	if err != nil {
+	return names, nil
+}
+
+func (s *Storage) RemoveFile(filename, bucketName string) error {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	if err := s.client.RemoveObject(ctx, bucketName, filename, minio.RemoveObjectOptions{}); err != nil {
+		s.logger.Error("failed remove file",
+			zap.String("filename", filename),
+			zap.String("bucket_name", bucketName),
+			zap.Error(err))
+
 		return err
 	}
 
-	fileInfo, err := tmpFile.Stat()
-	if err != nil {
-		return err
+	return nil
+}
+
+// RemovePrefix removes every object of the bucket whose name starts with
+// prefix.
+func (s *Storage) RemovePrefix(prefix, bucketName string) error {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	for obj := range s.client.ListObjects(ctx, bucketName, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			s.logger.Error("failed list files",
+				zap.String("prefix", prefix),
+				zap.String("bucket_name", bucketName),
+				zap.Error(obj.Err))
+
+			return obj.Err
+		}
+
+		if err := s.client.RemoveObject(ctx, bucketName, obj.Key, minio.RemoveObjectOptions{}); err != nil {
+			s.logger.Error("failed remove file",
+				zap.String("filename", obj.Key),
+				zap.String("bucket_name", bucketName),
+				zap.Error(err))
+
+			return err
+		}
 	}
 
-	newFileHeader := &multipart.FileHeader{
-		Filename: filepath.Base(fileHeader.Filename),
-		Size:     fileInfo.Size(),
-		Header:   make(map[string][]string),
+	return nil
+}
+
+func (s *Storage) PutBytes(data []byte, bucketName, objectName, contentType string) error {
+	ctx, cancel := s.context()
+	defer cancel()
+
+	_, err := s.client.PutObject(
+		ctx,
+		bucketName,
+		objectName,
+		bytes.NewReader(data),
+		int64(len(data)),
+		minio.PutObjectOptions{ContentType: contentType},
+	)
+	if err != nil {
+		s.logger.Error("failed put object",
+			zap.String("object_name", objectName),
+			zap.String("bucket_name", bucketName),
+			zap.Error(err))
+
+		return err
 	}
-	newFileHeader.Header.Set("Content-Type", "image/jpeg")
 
-	return s.UploadFile(newFileHeader, bucket)
+	return nil
 }
@@ -0,0 +1,60 @@
+package streams
+
+import (
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// Censor holds content sent out for review, the censor reads it.
+	Censor = "CENSOR"
+	// Verdicts holds the censor's answers until our consumers ack them.
+	Verdicts = "VERDICTS"
+	// Images holds upload processing jobs and the events announcing their
+	// results.
+	Images = "IMAGES"
+
+	MaxAge = 7 * 24 * time.Hour
+)
+
+var (
+	CensorSubjects  = []string{"articles", "news", "mems", "wallpapers"}
+	VerdictSubjects = []string{
+		"uncensored_articles",
+		"uncensored_news",
+		"uncensored_mems",
+		"uncensored_wallpapers",
+	}
+	ImageSubjects = []string{"images.jobs", "images.processed"}
+)
+
+// Ensure creates the stream or brings an existing one to cfg, so every
+// instance may call it on start.
+func Ensure(js nats.JetStreamContext, cfg *nats.StreamConfig) error {
+	_, err := js.StreamInfo(cfg.Name)
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		_, err = js.AddStream(cfg)
+
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = js.UpdateStream(cfg)
+
+	return err
+}
+
+func Config(name string, subjects []string) *nats.StreamConfig {
+	return &nats.StreamConfig{
+		Name:      name,
+		Subjects:  subjects,
+		Storage:   nats.FileStorage,
+		Retention: nats.LimitsPolicy,
+		MaxAge:    MaxAge,
+	}
+}
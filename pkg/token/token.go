@@ -0,0 +1,106 @@
+package token
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	KindAccess  = "access"
+	KindRefresh = "refresh"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrWrongKind    = errors.New("wrong token kind")
+)
+
+type (
+	Claims struct {
+		jwt.RegisteredClaims
+		Kind string `json:"kind"`
+	}
+
+	Pair struct {
+		AccessToken      string    `json:"access_token"`
+		RefreshToken     string    `json:"refresh_token"`
+		AccessExpiresAt  time.Time `json:"access_expires_at"`
+		RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	}
+
+	Manager struct {
+		secret     []byte
+		accessTTL  time.Duration
+		refreshTTL time.Duration
+	}
+)
+
+func NewManager(secret string, accessTTL, refreshTTL time.Duration) *Manager {
+	return &Manager{
+		secret:     []byte(secret),
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+func (m *Manager) Issue(subject string) (*Pair, error) {
+	now := time.Now()
+
+	access, accessExp, err := m.sign(subject, KindAccess, now, m.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, refreshExp, err := m.sign(subject, KindRefresh, now, m.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Pair{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		AccessExpiresAt:  accessExp,
+		RefreshExpiresAt: refreshExp,
+	}, nil
+}
+
+func (m *Manager) Parse(raw, kind string) (*Claims, error) {
+	var claims Claims
+
+	_, err := jwt.ParseWithClaims(raw, &claims, func(*jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.Kind != kind {
+		return nil, ErrWrongKind
+	}
+
+	return &claims, nil
+}
+
+func (m *Manager) sign(subject, kind string, now time.Time, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := now.Add(ttl)
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Kind: kind,
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return signed, expiresAt, nil
+}